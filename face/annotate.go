@@ -0,0 +1,63 @@
+package face
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+// annotateColors are cycled through so neighbouring boxes are easy to tell apart
+var annotateColors = []color.Color{
+	color.RGBA{R: 255, A: 255},
+	color.RGBA{G: 255, A: 255},
+	color.RGBA{B: 255, A: 255},
+	color.RGBA{R: 255, G: 255, A: 255},
+}
+
+// AnnotateFaces draws each Rekognition bounding box onto the image as a colored
+// rectangle and returns the result as a JPEG. It's meant as a debugging aid when
+// tuning crop scale or diagnosing mis-detections. The image is returned upright,
+// with any EXIF orientation applied, like the boxes Rekognition reports.
+func AnnotateFaces(img []byte, boxes []types.BoundingBox) ([]byte, error) {
+	// Bounding boxes refer to the upright image, so rotate before drawing
+	decoded, _, err := decodeUpright(img)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := decoded.Bounds()
+	canvas := image.NewRGBA(bounds)
+	draw.Draw(canvas, bounds, decoded, bounds.Min, draw.Src)
+
+	// Keep the outline visible on large photos
+	thickness := max(2, min(bounds.Dx(), bounds.Dy())/200)
+	for i, box := range boxes {
+		if box.Left == nil || box.Top == nil || box.Width == nil || box.Height == nil {
+			return nil, fmt.Errorf("%w: box %d is missing coordinates", ErrInvalidBoundingBox, i)
+		}
+		rect := scaledRect(bounds, box, 1).Intersect(bounds)
+		if rect.Empty() {
+			continue
+		}
+		drawOutline(canvas, rect, thickness, annotateColors[i%len(annotateColors)])
+	}
+
+	return encodeJPEG(canvas)
+}
+
+// drawOutline draws the border of rect, thickness pixels wide, inside rect
+func drawOutline(dst draw.Image, rect image.Rectangle, thickness int, c color.Color) {
+	src := image.NewUniform(c)
+	edges := []image.Rectangle{
+		image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+thickness),
+		image.Rect(rect.Min.X, rect.Max.Y-thickness, rect.Max.X, rect.Max.Y),
+		image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+thickness, rect.Max.Y),
+		image.Rect(rect.Max.X-thickness, rect.Min.Y, rect.Max.X, rect.Max.Y),
+	}
+	for _, edge := range edges {
+		draw.Draw(dst, edge.Intersect(rect), src, image.Point{}, draw.Src)
+	}
+}
@@ -0,0 +1,59 @@
+package face
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func TestAnnotateFaces(t *testing.T) {
+	source := testPNG(t, 200, 100)
+
+	annotated, err := AnnotateFaces(source, []types.BoundingBox{boundingBox(0.25, 0.25, 0.5, 0.5)})
+	if err != nil {
+		t.Fatalf("error annotating faces: %v", err)
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(annotated))
+	if err != nil {
+		t.Fatalf("error decoding annotated image: %v", err)
+	}
+	if format != "jpeg" {
+		t.Fatalf("got format %s, want jpeg", format)
+	}
+	if got := img.Bounds().Size(); got != image.Pt(200, 100) {
+		t.Fatalf("got size %v, want 200x100", got)
+	}
+
+	// The top-left corner of the box is drawn in the first annotate color
+	r, g, b, _ := img.At(51, 26).RGBA()
+	if r>>8 < 200 || g>>8 > 60 || b>>8 > 60 {
+		t.Fatalf("pixel on the outline is %d,%d,%d, want red", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestAnnotateFacesRotatedJPEG(t *testing.T) {
+	// Stored landscape, displayed portrait once rotated by 90 degrees
+	source := withExifOrientation(testJPEG(t, 200, 100), orientationRotate90)
+
+	annotated, err := AnnotateFaces(source, []types.BoundingBox{boundingBox(0.25, 0.25, 0.5, 0.5)})
+	if err != nil {
+		t.Fatalf("error annotating faces: %v", err)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(annotated))
+	if err != nil {
+		t.Fatalf("error decoding annotated image: %v", err)
+	}
+	if got := img.Bounds().Size(); got != image.Pt(100, 200) {
+		t.Fatalf("got size %v, want the upright 100x200", got)
+	}
+
+	// The top-left corner of the box is at (25, 50) of the upright image
+	r, g, b, _ := img.At(26, 51).RGBA()
+	if r>>8 < 200 || g>>8 > 60 || b>>8 > 60 {
+		t.Fatalf("pixel on the outline is %d,%d,%d, want red", r>>8, g>>8, b>>8)
+	}
+}
@@ -0,0 +1,107 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+	"github.com/aws/smithy-go"
+)
+
+// awsError wraps an error returned by a Rekognition operation with the AWS
+// request ID, which AWS support asks for when a ticket is escalated. An image
+// Rekognition can't read is also marked with ErrInvalidImageFormat.
+func awsError(operation string, err error) error {
+	var invalidFormat *types.InvalidImageFormatException
+	if errors.As(err, &invalidFormat) {
+		err = fmt.Errorf("%w: %w", ErrInvalidImageFormat, err)
+	}
+	if requestID := awsRequestID(err); requestID != "" {
+		return fmt.Errorf("%s failed [requestID=%s]: %w", operation, requestID, err)
+	}
+	return fmt.Errorf("%s failed: %w", operation, err)
+}
+
+// awsRequestID returns the request ID the SDK attached to the error, if any
+func awsRequestID(err error) string {
+	var responseErr *awshttp.ResponseError
+	if errors.As(err, &responseErr) {
+		return responseErr.ServiceRequestID()
+	}
+	return ""
+}
+
+// Category groups errors for alerting, e.g. as a CloudWatch metric dimension.
+type Category string
+
+const (
+	// CategoryThrottling is a request rejected for exceeding a rate or a service limit. Retry with backoff.
+	CategoryThrottling Category = "Throttling"
+	// CategoryTransient is a timeout or network failure. Retry.
+	CategoryTransient Category = "Transient"
+	// CategoryClientError is a request Rekognition can't serve as sent, such as an unreadable image. Don't retry.
+	CategoryClientError Category = "ClientError"
+	// CategoryServiceError is a failure on the Rekognition side.
+	CategoryServiceError Category = "ServiceError"
+	// CategoryNotFound is a missing collection, face or session.
+	CategoryNotFound Category = "NotFound"
+	// CategoryAccessDenied is a request the credentials aren't allowed to make.
+	CategoryAccessDenied Category = "AccessDenied"
+	// CategoryUnknown is any other error.
+	CategoryUnknown Category = "Unknown"
+)
+
+// ErrorCategory classifies an error returned by the package, looking through the wrapped
+// AWS error types. It returns "" for a nil error.
+func ErrorCategory(err error) Category {
+	if err == nil {
+		return ""
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "ResourceNotFoundException", "SessionNotFoundException":
+			return CategoryNotFound
+		case "AccessDeniedException":
+			return CategoryAccessDenied
+		case "ThrottlingException", "ProvisionedThroughputExceededException", "LimitExceededException", "ServiceQuotaExceededException":
+			return CategoryThrottling
+		}
+		if apiErr.ErrorFault() == smithy.FaultServer {
+			return CategoryServiceError
+		}
+		if apiErr.ErrorFault() == smithy.FaultClient {
+			return CategoryClientError
+		}
+	}
+
+	// Errors raised before any call was made
+	for _, clientErr := range []error{ErrUnsupportedImageFormat, ErrInvalidImageDimensions, ErrInvalidBoundingBox, ErrInvalidExternalImageId, ErrImageTooLarge, ErrEmptyImage, ErrInvalidCollectionId, ErrLowQuality} {
+		if errors.Is(err, clientErr) {
+			return CategoryClientError
+		}
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) ||
+		retry.IsErrorRetryables(retry.DefaultRetryables).IsErrorRetryable(err) == aws.TrueTernary {
+		return CategoryTransient
+	}
+
+	var responseErr *awshttp.ResponseError
+	if errors.As(err, &responseErr) {
+		switch status := responseErr.HTTPStatusCode(); {
+		case status >= 500:
+			return CategoryServiceError
+		case status >= 400:
+			return CategoryClientError
+		}
+	}
+	return CategoryUnknown
+}
@@ -0,0 +1,105 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// awsOperationError builds an error shaped like the ones the SDK returns
+func awsOperationError(operation string, requestID string, statusCode int, err error) error {
+	return &smithy.OperationError{
+		ServiceID:     "Rekognition",
+		OperationName: operation,
+		Err: &awshttp.ResponseError{
+			ResponseError: &smithyhttp.ResponseError{
+				Response: &smithyhttp.Response{Response: &http.Response{StatusCode: statusCode}},
+				Err:      err,
+			},
+			RequestID: requestID,
+		},
+	}
+}
+
+func TestAwsErrorIncludesRequestID(t *testing.T) {
+	throttled := &types.ThrottlingException{Message: new(string)}
+	fake := &fakeRekognition{
+		searchFacesByImage: func(*rekognition.SearchFacesByImageInput) (*rekognition.SearchFacesByImageOutput, error) {
+			return nil, awsOperationError("SearchFacesByImage", "req-123", 400, throttled)
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	_, err := faceIndexer.SearchFaceWithBucket(context.TODO(), "bucket", "key.jpg", "event_1")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(err.Error(), "SearchFacesByImage failed [requestID=req-123]") {
+		t.Fatalf("error %q doesn't include the request id", err)
+	}
+	var throttlingErr *types.ThrottlingException
+	if !errors.As(err, &throttlingErr) {
+		t.Fatalf("error %q doesn't wrap the AWS error", err)
+	}
+}
+
+func TestErrorCategory(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Category
+	}{
+		{"nil", nil, ""},
+		{"throttling", awsOperationError("IndexFaces", "req-1", 400, &types.ThrottlingException{}), CategoryThrottling},
+		{"throughput", awsOperationError("IndexFaces", "req-1", 400, &types.ProvisionedThroughputExceededException{}), CategoryThrottling},
+		{"not found", awsOperationError("SearchFaces", "req-1", 400, &types.ResourceNotFoundException{}), CategoryNotFound},
+		{"access denied", awsOperationError("SearchFaces", "req-1", 403, &types.AccessDeniedException{}), CategoryAccessDenied},
+		{"invalid parameter", awsOperationError("SearchFaces", "req-1", 400, &types.InvalidParameterException{}), CategoryClientError},
+		{"internal server error", awsOperationError("SearchFaces", "req-1", 500, &types.InternalServerError{}), CategoryServiceError},
+		{"local validation", fmt.Errorf("failed to index face: %w", ErrUnsupportedImageFormat), CategoryClientError},
+		{"deadline", fmt.Errorf("IndexFaces failed: %w", context.DeadlineExceeded), CategoryTransient},
+		{"unknown", errors.New("boom"), CategoryUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Errors reach callers wrapped by the indexer
+			err := tt.err
+			if err != nil {
+				err = fmt.Errorf("failed to index face: %w", awsError("IndexFaces", err))
+			}
+			if got := ErrorCategory(err); got != tt.want {
+				t.Fatalf("got category %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidImageFormatError(t *testing.T) {
+	fake := &fakeRekognition{
+		indexFaces: func(*rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			return nil, awsOperationError("IndexFaces", "req-1", 400, &types.InvalidImageFormatException{Message: new(string)})
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	err := faceIndexer.IndexFace(context.TODO(), testJPEG(t, 100, 100), "photo_1", "event_1")
+	if !errors.Is(err, ErrInvalidImageFormat) {
+		t.Fatalf("got %v, want ErrInvalidImageFormat", err)
+	}
+	var invalidFormat *types.InvalidImageFormatException
+	if !errors.As(err, &invalidFormat) {
+		t.Fatalf("error %q doesn't wrap the AWS error", err)
+	}
+	if got := ErrorCategory(err); got != CategoryClientError {
+		t.Fatalf("got category %q, want %q", got, CategoryClientError)
+	}
+}
@@ -0,0 +1,249 @@
+package face
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+	"github.com/samber/lo"
+)
+
+// BatchItem is one image to index in a batch.
+type BatchItem struct {
+	Image           []byte
+	ExternalImageId string
+}
+
+// BatchOptions configures IndexFacesBatch.
+type BatchOptions struct {
+	// Concurrency is the number of images indexed at the same time, 1 when unset.
+	Concurrency int
+}
+
+// EstimateOptions describes the collection a batch job will run against, see EstimateBatchOperations.
+type EstimateOptions struct {
+	// CollectionFaces is the number of faces already in the collection, which sets how many
+	// ListFaces pages WithIdempotentIndex reads.
+	CollectionFaces int
+}
+
+// IndexResult is the outcome of indexing one image.
+type IndexResult struct {
+	// ExternalImageId is the image's id as indexed, normalized with NormalizeExternalImageId
+	ExternalImageId string   `json:"externalImageId"`
+	FaceIds         []string `json:"faceIds"`
+	// ContentHash is the ContentHash of the image with WithSeenContent, to record it as seen
+	// once indexed; empty without it
+	ContentHash string `json:"contentHash,omitempty"`
+	Err         error  `json:"-"`
+}
+
+// MarshalJSON encodes the result with Err as its message under "error", omitted on success.
+func (r IndexResult) MarshalJSON() ([]byte, error) {
+	type result IndexResult
+	out := struct {
+		result
+		Error string `json:"error,omitempty"`
+	}{result: result(r)}
+	if r.Err != nil {
+		out.Error = r.Err.Error()
+	}
+	return json.Marshal(out)
+}
+
+// OperationEstimate is the projected number of Rekognition calls for a job.
+type OperationEstimate struct {
+	IndexFaces int `json:"indexFaces"`
+	// IndexFacesTimeoutRetries is the worst case of the IndexFaces retries with the DEFAULT
+	// detection attributes, which are only made when a call with ALL of them times out. It
+	// isn't part of Total.
+	IndexFacesTimeoutRetries int `json:"indexFacesTimeoutRetries"`
+	DescribeCollection       int `json:"describeCollection"`
+	// CreateCollection is an upper bound, it is only called when the collection doesn't exist yet.
+	CreateCollection int `json:"createCollection"`
+	// ListFaces are the pages WithIdempotentIndex reads to find each image's existing faces.
+	ListFaces int `json:"listFaces"`
+	// DeleteFaces is an upper bound, WithMinDetectionConfidence only deletes from images
+	// with a face below the threshold.
+	DeleteFaces int `json:"deleteFaces"`
+}
+
+// Total is the projected number of Rekognition calls of every kind, without the timeout retries.
+func (e OperationEstimate) Total() int {
+	return e.IndexFaces + e.DescribeCollection + e.CreateCollection + e.ListFaces + e.DeleteFaces
+}
+
+// EstimateBatchOperations projects the Rekognition calls IndexFacesBatch makes for itemCount images
+// with the given call options, on an indexer that hasn't touched the collection yet. It mirrors the
+// batching logic: the collection is ensured once up front, after which the collection cache makes
+// every IndexFaces call direct. WithIdempotentIndex lists the whole collection before each image,
+// which grows by about one face per image, so its pages depend on collection.CollectionFaces. Retries
+// of images Rekognition rejects as too large and throttling retries by the SDK aren't counted.
+// No API call is made.
+func EstimateBatchOperations(itemCount int, opts BatchOptions, collection EstimateOptions, callOpts ...CallOption) OperationEstimate {
+	if itemCount <= 0 {
+		return OperationEstimate{}
+	}
+	o := newCallOptions(callOpts)
+	estimate := OperationEstimate{
+		IndexFaces:         itemCount,
+		DescribeCollection: 1,
+		CreateCollection:   1,
+	}
+	if lo.Contains(o.detectionAttributes, types.AttributeAll) {
+		estimate.IndexFacesTimeoutRetries = itemCount
+	}
+	if o.idempotent {
+		for i := 0; i < itemCount; i++ {
+			// An empty collection still takes one call to list
+			estimate.ListFaces += max(1, (collection.CollectionFaces+i+listFacesPageSize-1)/listFacesPageSize)
+		}
+	}
+	if o.minDetectionConfidence > 0 {
+		estimate.DeleteFaces = itemCount
+	}
+	return estimate
+}
+
+// IndexFacesBatch indexes every item into the collection with bounded concurrency. The collection is
+// ensured once before any image is indexed. Per-image failures are reported in the matching
+// IndexResult, which are returned in item order, and joined in the returned error; with
+// WithErrorPolicy(FailFast) the error is the first failure instead. Images left unindexed because
+// the batch stopped or ctx was done report the context's error in their IndexResult.
+func (r *rekognitionFaceIndexer) IndexFacesBatch(ctx context.Context, collectionId string, items []BatchItem, opts BatchOptions, callOpts ...CallOption) ([]IndexResult, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	// Ensure the collection once so the workers don't race to create it
+	if err := r.createCollectionIfNotExists(ctx, r.client, collectionId, newCallOptions(callOpts)); err != nil {
+		return nil, fmt.Errorf("failed to ensure collection exists: %w", err)
+	}
+
+	externalImageId := func(i int) string { return items[i].ExternalImageId }
+	results, failed, err := indexConcurrently(ctx, len(items), opts.Concurrency, newCallOptions(callOpts).errorPolicy, externalImageId, func(ctx context.Context, i int) IndexResult {
+		return r.indexBatchItem(ctx, items[i], collectionId, callOpts)
+	})
+	if failed != nil {
+		return results, fmt.Errorf("index faces batch failed: %w", failed)
+	}
+	if err != nil {
+		return results, fmt.Errorf("index faces batch cancelled: %w", err)
+	}
+	return results, nil
+}
+
+// ErrorPolicy is what a batch does when indexing one of its images fails, see WithErrorPolicy.
+type ErrorPolicy int
+
+const (
+	// ContinueOnError indexes every image, reports each failure in its IndexResult and
+	// returns them all joined.
+	ContinueOnError ErrorPolicy = iota
+	// FailFast stops the batch at the first failure, cancelling the images in flight,
+	// and returns that failure.
+	FailFast
+)
+
+// indexConcurrently runs index for 0 to n-1 with up to concurrency calls at a time and returns
+// the results in order. With FailFast, the first failed result cancels the context of the calls
+// in flight, stops handing out work and is returned as failed; with ContinueOnError, failed joins
+// the failures of every image. Images left unindexed because the batch stopped get a result for
+// their normalized externalImageId(i) with the context's error, and count as failures. err is the
+// context's error when ctx is done before every image was indexed.
+func indexConcurrently(ctx context.Context, n int, concurrency int, policy ErrorPolicy, externalImageId func(i int) string, index func(ctx context.Context, i int) IndexResult) (results []IndexResult, failed error, err error) {
+	batchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var once sync.Once
+	results = make([]IndexResult, n)
+	indexed := make([]bool, n)
+	err = runBatch(batchCtx, n, concurrency, func(i int) {
+		results[i] = index(batchCtx, i)
+		indexed[i] = true
+		if policy == FailFast && results[i].Err != nil {
+			once.Do(func() {
+				failed = fmt.Errorf("failed to index %s: %w", results[i].ExternalImageId, results[i].Err)
+				cancel()
+			})
+		}
+	})
+	for i := range results {
+		if !indexed[i] {
+			results[i] = IndexResult{ExternalImageId: NormalizeExternalImageId(externalImageId(i)), Err: batchCtx.Err()}
+		}
+	}
+	if failed != nil {
+		return results, failed, nil
+	}
+
+	var failures []error
+	for _, result := range results {
+		if result.Err != nil {
+			failures = append(failures, fmt.Errorf("failed to index %s: %w", result.ExternalImageId, result.Err))
+		}
+	}
+	return results, errors.Join(failures...), err
+}
+
+// runBatch calls index for 0 to n-1 with up to concurrency calls at a time, 1 when unset.
+// It stops handing out work once ctx is done and returns the context's error.
+func runBatch(ctx context.Context, n int, concurrency int, index func(i int)) error {
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(concurrency, 1), n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				// Work handed out just before ctx was done is dropped too
+				if ctx.Err() != nil {
+					continue
+				}
+				index(i)
+			}
+		}()
+	}
+
+	var err error
+	for i := 0; i < n; i++ {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+	return err
+}
+
+func (r *rekognitionFaceIndexer) indexBatchItem(ctx context.Context, item BatchItem, collectionId string, callOpts []CallOption) IndexResult {
+	result := IndexResult{ExternalImageId: NormalizeExternalImageId(item.ExternalImageId)}
+	resp, hash, err := r.indexFaceBytesHashed(ctx, item.Image, item.ExternalImageId, collectionId, newCallOptions(callOpts))
+	result.ContentHash = hash
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.FaceIds = faceIds(resp)
+	return result
+}
+
+// faceIds returns the FaceIds of the faces indexed by IndexFaces
+func faceIds(resp *rekognition.IndexFacesOutput) []string {
+	var ids []string
+	for _, faceRecord := range resp.FaceRecords {
+		if faceRecord.Face != nil {
+			ids = append(ids, aws.ToString(faceRecord.Face.FaceId))
+		}
+	}
+	return ids
+}
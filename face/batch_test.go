@@ -0,0 +1,177 @@
+package face
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func TestIndexFacesBatchMatchesEstimate(t *testing.T) {
+	fake := &fakeRekognition{}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+	imageBytes := testJPEG(t, 100, 100)
+
+	items := make([]BatchItem, 20)
+	for i := range items {
+		items[i] = BatchItem{Image: imageBytes, ExternalImageId: fmt.Sprintf("photo_%d", i)}
+	}
+	items[7].Image = []byte("corrupt")
+
+	opts := BatchOptions{Concurrency: 4}
+	results, err := faceIndexer.IndexFacesBatch(context.TODO(), "event_1", items, opts)
+	if !errors.Is(err, ErrUnsupportedImageFormat) {
+		t.Fatalf("got error %v, want the failure of the corrupt image", err)
+	}
+	for i, result := range results {
+		if result.ExternalImageId != items[i].ExternalImageId {
+			t.Fatalf("result %d is for %s, want %s", i, result.ExternalImageId, items[i].ExternalImageId)
+		}
+		if i == 7 {
+			if !errors.Is(result.Err, ErrUnsupportedImageFormat) {
+				t.Fatalf("got error %v for the corrupt image, want %v", result.Err, ErrUnsupportedImageFormat)
+			}
+			continue
+		}
+		if result.Err != nil || len(result.FaceIds) != 1 {
+			t.Fatalf("result %d: got %+v", i, result)
+		}
+	}
+
+	estimate := EstimateBatchOperations(len(items), opts, EstimateOptions{})
+	if got := fake.count("DescribeCollection"); got != estimate.DescribeCollection {
+		t.Fatalf("DescribeCollection called %d times, estimated %d", got, estimate.DescribeCollection)
+	}
+	// The corrupt image is rejected before any call, the estimate is an upper bound
+	if got := fake.count("IndexFaces"); got != estimate.IndexFaces-1 {
+		t.Fatalf("IndexFaces called %d times, estimated %d", got, estimate.IndexFaces)
+	}
+	if estimate.Total() != 22 {
+		t.Fatalf("got total %d, want 22", estimate.Total())
+	}
+}
+
+func TestIndexResultJSON(t *testing.T) {
+	tests := []struct {
+		result IndexResult
+		want   string
+	}{
+		{IndexResult{ExternalImageId: "photo_1", FaceIds: []string{"face-1"}}, `{"externalImageId":"photo_1","faceIds":["face-1"]}`},
+		{IndexResult{ExternalImageId: "photo_2", Err: ErrNoFaceIndexed}, `{"externalImageId":"photo_2","faceIds":null,"error":"no face indexed"}`},
+	}
+	for _, tt := range tests {
+		out, err := json.Marshal(tt.result)
+		if err != nil {
+			t.Fatalf("error marshaling result: %v", err)
+		}
+		if string(out) != tt.want {
+			t.Fatalf("got %s, want %s", out, tt.want)
+		}
+	}
+}
+
+func TestIndexFacesBatchSkipsSeenContent(t *testing.T) {
+	fake := &fakeRekognition{}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+	unchanged, changed := testJPEG(t, 100, 100), testJPEG(t, 120, 100)
+	seen := map[string]bool{ContentHash(unchanged): true}
+
+	items := []BatchItem{{Image: unchanged, ExternalImageId: "photo_1"}, {Image: changed, ExternalImageId: "photo_2"}}
+	results, err := faceIndexer.IndexFacesBatch(context.TODO(), "event_1", items, BatchOptions{}, WithSeenContent(func(hash string) bool {
+		return seen[hash]
+	}))
+	if !errors.Is(err, ErrAlreadyIndexed) {
+		t.Fatalf("got error %v, want %v", err, ErrAlreadyIndexed)
+	}
+	if !errors.Is(results[0].Err, ErrAlreadyIndexed) {
+		t.Fatalf("got error %v for the unchanged photo, want %v", results[0].Err, ErrAlreadyIndexed)
+	}
+	if results[1].Err != nil || results[1].ContentHash != ContentHash(changed) {
+		t.Fatalf("got %+v for the changed photo", results[1])
+	}
+	if got := fake.count("IndexFaces"); got != 1 {
+		t.Fatalf("IndexFaces called %d times, want 1", got)
+	}
+
+	// Images aren't hashed without a callback to consult
+	results, err = faceIndexer.IndexFacesBatch(context.TODO(), "event_1", items[1:], BatchOptions{})
+	if err != nil || results[0].ContentHash != "" {
+		t.Fatalf("got %+v, %v without WithSeenContent, want no content hash", results[0], err)
+	}
+}
+
+func TestIndexFacesBatchFailFast(t *testing.T) {
+	fake := &fakeRekognition{}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+	imageBytes := testJPEG(t, 100, 100)
+
+	items := make([]BatchItem, 5)
+	for i := range items {
+		items[i] = BatchItem{Image: imageBytes, ExternalImageId: fmt.Sprintf("photo_%d", i)}
+	}
+	items[1].Image = []byte("corrupt")
+
+	results, err := faceIndexer.IndexFacesBatch(context.TODO(), "event_1", items, BatchOptions{}, WithErrorPolicy(FailFast))
+	if !errors.Is(err, ErrUnsupportedImageFormat) {
+		t.Fatalf("got error %v, want %v", err, ErrUnsupportedImageFormat)
+	}
+	if len(results[0].FaceIds) != 1 || results[2].ExternalImageId != "photo_2" || !errors.Is(results[2].Err, context.Canceled) {
+		t.Fatalf("got results %+v, want only photo_0 indexed", results)
+	}
+	if got := fake.count("IndexFaces"); got != 1 {
+		t.Fatalf("IndexFaces called %d times, want 1", got)
+	}
+}
+
+func TestIndexFacesBatchCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	fake := &fakeRekognition{
+		indexFaces: func(input *rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			cancel()
+			return &rekognition.IndexFacesOutput{FaceRecords: []types.FaceRecord{{Face: &types.Face{FaceId: aws.String("face-1")}}}}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+	imageBytes := testJPEG(t, 100, 100)
+	items := []BatchItem{{Image: imageBytes, ExternalImageId: "photo_1"}, {Image: imageBytes, ExternalImageId: "photo 2"}, {Image: imageBytes, ExternalImageId: "photo_3"}}
+
+	results, err := faceIndexer.IndexFacesBatch(ctx, "event_1", items, BatchOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want %v", err, context.Canceled)
+	}
+	if results[0].Err != nil || len(results[0].FaceIds) != 1 {
+		t.Fatalf("got %+v for photo_1, want it indexed", results[0])
+	}
+	// The images never indexed aren't mistaken for successes
+	for i, want := range []string{"photo_202", "photo_3"} {
+		if result := results[i+1]; result.ExternalImageId != want || !errors.Is(result.Err, context.Canceled) {
+			t.Fatalf("got %+v, want %s cancelled", result, want)
+		}
+	}
+}
+
+func TestEstimateBatchOperationsWithCallOptions(t *testing.T) {
+	estimate := EstimateBatchOperations(3, BatchOptions{}, EstimateOptions{CollectionFaces: listFacesPageSize - 1}, WithIdempotentIndex(), WithDetectionAttributes(types.AttributeAll), WithMinDetectionConfidence(90))
+	// The collection outgrows a single ListFaces page while the third image is checked
+	want := OperationEstimate{IndexFaces: 3, IndexFacesTimeoutRetries: 3, DescribeCollection: 1, CreateCollection: 1, ListFaces: 4, DeleteFaces: 3}
+	if estimate != want {
+		t.Fatalf("got %+v, want %+v", estimate, want)
+	}
+
+	// The idempotency check lists the collection before every image
+	fake := &fakeRekognition{}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+	items := []BatchItem{{Image: testJPEG(t, 100, 100), ExternalImageId: "photo_1"}, {Image: testJPEG(t, 100, 100), ExternalImageId: "photo_2"}}
+	if _, err := faceIndexer.IndexFacesBatch(context.TODO(), "event_1", items, BatchOptions{}, WithIdempotentIndex()); err != nil {
+		t.Fatalf("error indexing batch: %v", err)
+	}
+	if got, want := fake.count("ListFaces"), EstimateBatchOperations(len(items), BatchOptions{}, EstimateOptions{}, WithIdempotentIndex()).ListFaces; got != want {
+		t.Fatalf("ListFaces called %d times, estimated %d", got, want)
+	}
+}
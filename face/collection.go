@@ -0,0 +1,136 @@
+package face
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+)
+
+// maxCollectionIdLength is the longest collection id Rekognition accepts
+const maxCollectionIdLength = 255
+
+// validateCollectionId rejects collection ids Rekognition would reject with a ValidationException,
+// so programming errors surface before any call is made. Ids are 1-255 characters of
+// a-z, A-Z, 0-9, '_', '.' and '-'.
+func validateCollectionId(collectionId string) error {
+	if collectionId == "" {
+		return fmt.Errorf("%w: collection id is empty", ErrInvalidCollectionId)
+	}
+	if len(collectionId) > maxCollectionIdLength {
+		return fmt.Errorf("%w: collection id is longer than %d characters", ErrInvalidCollectionId, maxCollectionIdLength)
+	}
+	for _, c := range collectionId {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '-') {
+			return fmt.Errorf("%w: %q contains %q", ErrInvalidCollectionId, collectionId, c)
+		}
+	}
+	return nil
+}
+
+// ApproxBytesPerFace is the storage allowance used by EstimateCollectionStorage
+// for a single indexed face. Rekognition doesn't publish the size of a stored
+// face vector, so this is a rough figure covering the vector and the metadata
+// kept with it (FaceId, ImageId, ExternalImageId, bounding box).
+const ApproxBytesPerFace int64 = 4 * 1024
+
+// EstimateCollectionStorage approximates the storage footprint of a collection as
+// its FaceCount multiplied by ApproxBytesPerFace. It's an estimate, not a billing figure.
+func (r *rekognitionFaceIndexer) EstimateCollectionStorage(ctx context.Context, collectionId string) (int64, int64, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return 0, 0, err
+	}
+	resp, err := invoke(ctx, r, "DescribeCollection", r.client.DescribeCollection, &rekognition.DescribeCollectionInput{
+		CollectionId: aws.String(collectionId),
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to describe collection: %w", err)
+	}
+
+	faceCount := aws.ToInt64(resp.FaceCount)
+	return faceCount, faceCount * ApproxBytesPerFace, nil
+}
+
+// CollectionInfo describes a collection.
+type CollectionInfo struct {
+	CollectionId     string    `json:"collectionId"`
+	CollectionArn    string    `json:"collectionArn"`
+	FaceCount        int64     `json:"faceCount"`
+	UserCount        int64     `json:"userCount"`
+	FaceModelVersion string    `json:"faceModelVersion"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+// DescribeCollection returns the face count, face model version and creation time of the collection
+func (r *rekognitionFaceIndexer) DescribeCollection(ctx context.Context, collectionId string) (CollectionInfo, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return CollectionInfo{}, err
+	}
+	resp, err := invoke(ctx, r, "DescribeCollection", r.client.DescribeCollection, &rekognition.DescribeCollectionInput{
+		CollectionId: aws.String(collectionId),
+	})
+	if err != nil {
+		return CollectionInfo{}, fmt.Errorf("failed to describe collection: %w", err)
+	}
+
+	return CollectionInfo{
+		CollectionId:     collectionId,
+		CollectionArn:    aws.ToString(resp.CollectionARN),
+		FaceCount:        aws.ToInt64(resp.FaceCount),
+		UserCount:        aws.ToInt64(resp.UserCount),
+		FaceModelVersion: aws.ToString(resp.FaceModelVersion),
+		CreatedAt:        aws.ToTime(resp.CreationTimestamp),
+	}, nil
+}
+
+// WarmCollection ensures the collection exists and makes a trivial ListFaces call on it, e.g.
+// ahead of an event's photo rush, so the first real search doesn't pay for a cold collection.
+func (r *rekognitionFaceIndexer) WarmCollection(ctx context.Context, collectionId string) error {
+	if err := validateCollectionId(collectionId); err != nil {
+		return err
+	}
+	if err := r.createCollectionIfNotExists(ctx, r.client, collectionId, callOptions{}); err != nil {
+		return fmt.Errorf("failed to warm collection: %w", err)
+	}
+	_, err := invoke(ctx, r, "ListFaces", r.client.ListFaces, &rekognition.ListFacesInput{
+		CollectionId: aws.String(collectionId),
+		MaxResults:   aws.Int32(1),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to warm collection: %w", err)
+	}
+	return nil
+}
+
+// AssertCollectionAccessible checks with DescribeCollection that the collection can be used,
+// e.g. at startup. A missing collection fails with ErrCollectionNotFound and a refused one with
+// ErrCollectionAccessDenied; both name the client's region, since a collection created in
+// another region is reported as missing.
+func (r *rekognitionFaceIndexer) AssertCollectionAccessible(ctx context.Context, collectionId string) error {
+	if err := validateCollectionId(collectionId); err != nil {
+		return err
+	}
+	_, err := invoke(ctx, r, "DescribeCollection", r.client.DescribeCollection, &rekognition.DescribeCollectionInput{
+		CollectionId: aws.String(collectionId),
+	})
+	switch ErrorCategory(err) {
+	case "":
+		return nil
+	case CategoryNotFound:
+		return fmt.Errorf("%w: %s in region %q, check the region it was created in: %w", ErrCollectionNotFound, collectionId, r.region(), err)
+	case CategoryAccessDenied:
+		return fmt.Errorf("%w: %s in region %q: %w", ErrCollectionAccessDenied, collectionId, r.region(), err)
+	default:
+		return fmt.Errorf("failed to check collection %s: %w", collectionId, err)
+	}
+}
+
+// region is the AWS region of the client, empty when it can't be told
+func (r *rekognitionFaceIndexer) region() string {
+	if client, ok := r.client.(interface{ Options() rekognition.Options }); ok {
+		return client.Options().Region
+	}
+	return ""
+}
@@ -0,0 +1,39 @@
+package face
+
+import (
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// collectionCache remembers collections known to exist so repeated indexing
+// into the same collection doesn't call DescribeCollection every time.
+// The zero value is ready to use and safe for concurrent use.
+type collectionCache struct {
+	mu sync.RWMutex
+	// known maps each collection to its face model version, empty when it isn't known
+	known map[string]string
+	// ensuring coalesces concurrent checks of the same unknown collection, keyed by collectionId
+	ensuring singleflight.Group
+}
+
+func (c *collectionCache) has(collectionId string) bool {
+	_, ok := c.faceModelVersion(collectionId)
+	return ok
+}
+
+func (c *collectionCache) faceModelVersion(collectionId string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	version, ok := c.known[collectionId]
+	return version, ok
+}
+
+func (c *collectionCache) add(collectionId string, faceModelVersion string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.known == nil {
+		c.known = make(map[string]string)
+	}
+	c.known[collectionId] = faceModelVersion
+}
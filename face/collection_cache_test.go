@@ -0,0 +1,101 @@
+package face
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func TestCollectionCacheSkipsDescribe(t *testing.T) {
+	fake := &fakeRekognition{}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+	image := testJPEG(t, 100, 100)
+
+	ctx := context.TODO()
+	for i := 0; i < 3; i++ {
+		if err := faceIndexer.IndexFace(ctx, image, fmt.Sprintf("image_%d", i), "event_1"); err != nil {
+			t.Fatalf("error indexing face: %v", err)
+		}
+	}
+
+	if got := fake.count("DescribeCollection"); got != 1 {
+		t.Fatalf("DescribeCollection called %d times, want 1", got)
+	}
+	if got := fake.count("IndexFaces"); got != 3 {
+		t.Fatalf("IndexFaces called %d times, want 3", got)
+	}
+}
+
+func TestConcurrentFirstIndexCreatesCollectionOnce(t *testing.T) {
+	release := make(chan struct{})
+	fake := &fakeRekognition{
+		describeCollection: func(*rekognition.DescribeCollectionInput) (*rekognition.DescribeCollectionOutput, error) {
+			<-release
+			return nil, &types.ResourceNotFoundException{Message: aws.String("collection not found")}
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+	image := testJPEG(t, 100, 100)
+
+	ctx := context.TODO()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := faceIndexer.IndexFace(ctx, image, fmt.Sprintf("image_%d", i), "event_new"); err != nil {
+				t.Errorf("error indexing face: %v", err)
+			}
+		}(i)
+	}
+	// Let every goroutine reach the collection check before it resolves
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := fake.count("CreateCollection"); got != 1 {
+		t.Fatalf("CreateCollection called %d times, want 1", got)
+	}
+	if got := fake.count("IndexFaces"); got != 10 {
+		t.Fatalf("IndexFaces called %d times, want 10", got)
+	}
+}
+
+// Run with -race to catch unguarded access to the indexer's shared state.
+func TestIndexerConcurrentUse(t *testing.T) {
+	fake := &fakeRekognition{}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+	image := testJPEG(t, 100, 100)
+
+	ctx := context.TODO()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			collectionId := fmt.Sprintf("event_%d", i%5)
+			if err := faceIndexer.IndexFace(ctx, image, fmt.Sprintf("image_%d", i), collectionId); err != nil {
+				t.Errorf("error indexing face: %v", err)
+			}
+			if _, err := faceIndexer.SearchFacebyFaceId(ctx, "face-1", collectionId); err != nil {
+				t.Errorf("error searching face: %v", err)
+			}
+			if _, err := faceIndexer.SearchFaceWithBucket(ctx, "bucket", "key.jpg", collectionId); err != nil {
+				t.Errorf("error searching face: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < 5; i++ {
+		if !faceIndexer.collections.has(fmt.Sprintf("event_%d", i)) {
+			t.Fatalf("collection event_%d not cached", i)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package face
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func TestEstimateCollectionStorage(t *testing.T) {
+	fake := &fakeRekognition{
+		describeCollection: func(*rekognition.DescribeCollectionInput) (*rekognition.DescribeCollectionOutput, error) {
+			return &rekognition.DescribeCollectionOutput{FaceCount: aws.Int64(250)}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	faceCount, approxBytes, err := faceIndexer.EstimateCollectionStorage(context.TODO(), "event_1")
+	if err != nil {
+		t.Fatalf("error estimating storage: %v", err)
+	}
+	if faceCount != 250 {
+		t.Fatalf("faceCount = %d, want 250", faceCount)
+	}
+	if approxBytes != 250*ApproxBytesPerFace {
+		t.Fatalf("approxBytes = %d, want %d", approxBytes, 250*ApproxBytesPerFace)
+	}
+}
+
+func TestDescribeCollection(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	fake := &fakeRekognition{
+		describeCollection: func(*rekognition.DescribeCollectionInput) (*rekognition.DescribeCollectionOutput, error) {
+			return &rekognition.DescribeCollectionOutput{
+				CollectionARN:     aws.String("arn:aws:rekognition:us-east-1:123456789012:collection/event_1"),
+				FaceCount:         aws.Int64(250),
+				UserCount:         aws.Int64(3),
+				FaceModelVersion:  aws.String("7.0"),
+				CreationTimestamp: &created,
+			}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	info, err := faceIndexer.DescribeCollection(context.TODO(), "event_1")
+	if err != nil {
+		t.Fatalf("error describing collection: %v", err)
+	}
+	out, err := json.Marshal(info)
+	if err != nil {
+		t.Fatalf("error marshaling collection info: %v", err)
+	}
+	want := `{"collectionId":"event_1","collectionArn":"arn:aws:rekognition:us-east-1:123456789012:collection/event_1","faceCount":250,"userCount":3,"faceModelVersion":"7.0","createdAt":"2024-05-01T12:00:00Z"}`
+	if string(out) != want {
+		t.Fatalf("got %s, want %s", out, want)
+	}
+}
+
+func TestWarmCollection(t *testing.T) {
+	var maxResults []int32
+	fake := &fakeRekognition{
+		describeCollection: func(*rekognition.DescribeCollectionInput) (*rekognition.DescribeCollectionOutput, error) {
+			return nil, &types.ResourceNotFoundException{Message: aws.String("collection not found")}
+		},
+		listFaces: func(input *rekognition.ListFacesInput) (*rekognition.ListFacesOutput, error) {
+			maxResults = append(maxResults, aws.ToInt32(input.MaxResults))
+			return &rekognition.ListFacesOutput{}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	if err := faceIndexer.WarmCollection(context.TODO(), "event_1"); err != nil {
+		t.Fatalf("error warming collection: %v", err)
+	}
+	if fake.count("CreateCollection") != 1 || len(maxResults) != 1 || maxResults[0] != 1 {
+		t.Fatalf("got %d CreateCollection calls and ListFaces of %v, want 1 and [1]", fake.count("CreateCollection"), maxResults)
+	}
+	if !faceIndexer.collections.has("event_1") {
+		t.Fatal("warmed collection not cached")
+	}
+}
+
+func TestAssertCollectionAccessible(t *testing.T) {
+	tests := []struct {
+		collectionId string
+		wantErr      error
+	}{
+		{collectionId: "event_1"},
+		{collectionId: "missing", wantErr: ErrCollectionNotFound},
+		{collectionId: "forbidden", wantErr: ErrCollectionAccessDenied},
+	}
+	fake := &fakeRekognition{
+		describeCollection: func(input *rekognition.DescribeCollectionInput) (*rekognition.DescribeCollectionOutput, error) {
+			switch aws.ToString(input.CollectionId) {
+			case "missing":
+				return nil, awsOperationError("DescribeCollection", "req-1", 400, &types.ResourceNotFoundException{})
+			case "forbidden":
+				return nil, awsOperationError("DescribeCollection", "req-2", 400, &types.AccessDeniedException{})
+			}
+			return &rekognition.DescribeCollectionOutput{}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	for _, tt := range tests {
+		err := faceIndexer.AssertCollectionAccessible(context.TODO(), tt.collectionId)
+		if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil) != (err == nil) {
+			t.Fatalf("collection %s: got error %v, want %v", tt.collectionId, err, tt.wantErr)
+		}
+	}
+}
+
+func TestEnsureCollection(t *testing.T) {
+	fake := &fakeRekognition{
+		describeCollection: func(*rekognition.DescribeCollectionInput) (*rekognition.DescribeCollectionOutput, error) {
+			return nil, &types.ResourceNotFoundException{Message: aws.String("collection not found")}
+		},
+		createCollection: func(*rekognition.CreateCollectionInput) (*rekognition.CreateCollectionOutput, error) {
+			return &rekognition.CreateCollectionOutput{FaceModelVersion: aws.String("7.0")}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake, options: newOptions([]Option{WithFaceModelVersion("7.0")})}
+
+	for i := 0; i < 2; i++ {
+		version, err := faceIndexer.EnsureCollection(context.TODO(), "event_1")
+		if err != nil {
+			t.Fatalf("error ensuring collection: %v", err)
+		}
+		if version != "7.0" {
+			t.Fatalf("got face model version %q, want 7.0", version)
+		}
+	}
+	if got := fake.count("CreateCollection"); got != 1 {
+		t.Fatalf("got %d CreateCollection calls, want 1", got)
+	}
+}
+
+func TestEnsureCollectionFaceModelVersionMismatch(t *testing.T) {
+	fake := &fakeRekognition{
+		describeCollection: func(*rekognition.DescribeCollectionInput) (*rekognition.DescribeCollectionOutput, error) {
+			return &rekognition.DescribeCollectionOutput{FaceModelVersion: aws.String("6.0")}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake, options: newOptions([]Option{WithFaceModelVersion("7.0")})}
+
+	if _, err := faceIndexer.EnsureCollection(context.TODO(), "event_1"); !errors.Is(err, ErrFaceModelVersionMismatch) {
+		t.Fatalf("got error %v, want %v", err, ErrFaceModelVersionMismatch)
+	}
+	err := faceIndexer.IndexFace(context.TODO(), testJPEG(t, 100, 100), "photo_1", "event_1")
+	if !errors.Is(err, ErrFaceModelVersionMismatch) {
+		t.Fatalf("got error %v indexing, want %v", err, ErrFaceModelVersionMismatch)
+	}
+	if got := fake.count("IndexFaces"); got != 0 {
+		t.Fatalf("got %d IndexFaces calls, want none", got)
+	}
+}
+
+func TestValidateCollectionId(t *testing.T) {
+	tests := []struct {
+		collectionId string
+		valid        bool
+	}{
+		{"event_1", true},
+		{"Event.2024-05", true},
+		{"", false},
+		{"event 1", false},
+		{"event/1", false},
+		{strings.Repeat("a", 256), false},
+	}
+	for _, tt := range tests {
+		err := validateCollectionId(tt.collectionId)
+		if tt.valid && err != nil {
+			t.Fatalf("got error %v for %q, want it valid", err, tt.collectionId)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidCollectionId) {
+			t.Fatalf("got error %v for %q, want ErrInvalidCollectionId", err, tt.collectionId)
+		}
+	}
+}
+
+func TestPublicMethodsRejectInvalidInputUpFront(t *testing.T) {
+	fake := &fakeRekognition{}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	ctx := context.TODO()
+	if err := faceIndexer.IndexFace(ctx, testJPEG(t, 100, 100), "photo_1", ""); !errors.Is(err, ErrInvalidCollectionId) {
+		t.Fatalf("got %v, want ErrInvalidCollectionId", err)
+	}
+	if _, err := faceIndexer.SearchFacebyFaceId(ctx, "face-1", ""); !errors.Is(err, ErrInvalidCollectionId) {
+		t.Fatalf("got %v, want ErrInvalidCollectionId", err)
+	}
+	if _, err := faceIndexer.SearchFaceAcrossCollections(ctx, testJPEG(t, 100, 100), []string{"event_1", ""}, 0); !errors.Is(err, ErrInvalidCollectionId) {
+		t.Fatalf("got %v, want ErrInvalidCollectionId", err)
+	}
+	if err := faceIndexer.IndexFace(ctx, nil, "photo_1", "event_1"); !errors.Is(err, ErrEmptyImage) {
+		t.Fatalf("got %v, want ErrEmptyImage", err)
+	}
+	if _, err := faceIndexer.ExtractFaces(ctx, []byte{}, 1); !errors.Is(err, ErrEmptyImage) {
+		t.Fatalf("got %v, want ErrEmptyImage", err)
+	}
+	for _, op := range []string{"CreateCollection", "DescribeCollection", "IndexFaces", "SearchFaces", "SearchFacesByImage", "DetectFaces"} {
+		if fake.count(op) != 0 {
+			t.Fatalf("%s was called for invalid input", op)
+		}
+	}
+}
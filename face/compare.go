@@ -0,0 +1,77 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+	"github.com/samber/lo"
+)
+
+// AreSamePerson reports whether the largest face in a and the largest face in b belong to the
+// same person, i.e. their similarity (0-100) is at least threshold, without any collection.
+// The similarity is returned either way. Both images are checked with DetectFaces first, so
+// it fails with ErrNoFaceInSource when a has no face and ErrNoFaceInTarget when b has none,
+// both of which match ErrNoFaceDetected.
+func (r *rekognitionFaceIndexer) AreSamePerson(ctx context.Context, a []byte, b []byte, threshold float32) (bool, float32, error) {
+	// Reject images Rekognition can't read before making any call
+	for _, image := range [][]byte{a, b} {
+		if _, err := validateImage(image); err != nil {
+			return false, 0, fmt.Errorf("failed to compare faces: %w", err)
+		}
+	}
+
+	// CompareFaces doesn't say which image has no face, so tell the caller first
+	for _, input := range []struct {
+		image   []byte
+		noFaces error
+	}{{image: a, noFaces: ErrNoFaceInSource}, {image: b, noFaces: ErrNoFaceInTarget}} {
+		faces, err := r.detectFaces(ctx, input.image, nil, callOptions{})
+		if err != nil {
+			return false, 0, fmt.Errorf("failed to compare faces: %w", err)
+		}
+		if len(faces) == 0 {
+			return false, 0, fmt.Errorf("failed to compare faces: %w", input.noFaces)
+		}
+	}
+
+	// Rekognition compares the largest face of the source image. A threshold of 0 returns
+	// every face of the target as a match, so the largest one can be picked.
+	resp, err := invoke(ctx, r, "CompareFaces", r.client.CompareFaces, &rekognition.CompareFacesInput{
+		SourceImage:         &types.Image{Bytes: a},
+		TargetImage:         &types.Image{Bytes: b},
+		SimilarityThreshold: aws.Float32(0),
+	})
+	if err != nil {
+		// CompareFaces rejects a source image without a face as an invalid parameter
+		var invalidParamErr *types.InvalidParameterException
+		if errors.As(err, &invalidParamErr) {
+			return false, 0, fmt.Errorf("failed to compare faces: %w: %w", ErrNoFaceDetected, err)
+		}
+		return false, 0, fmt.Errorf("failed to compare faces: %w", err)
+	}
+
+	matches := lo.Filter(resp.FaceMatches, func(match types.CompareFacesMatch, _ int) bool {
+		return match.Face != nil
+	})
+	if len(matches) == 0 && len(resp.UnmatchedFaces) == 0 {
+		return false, 0, fmt.Errorf("failed to compare faces: %w", ErrNoFaceDetected)
+	}
+
+	// The largest face of b may still be reported as unmatched despite the threshold
+	largestMatch := lo.MaxBy(matches, func(x, y types.CompareFacesMatch) bool {
+		return boundingBoxArea(x.Face.BoundingBox) > boundingBoxArea(y.Face.BoundingBox)
+	})
+	largestUnmatched := lo.MaxBy(resp.UnmatchedFaces, func(x, y types.ComparedFace) bool {
+		return boundingBoxArea(x.BoundingBox) > boundingBoxArea(y.BoundingBox)
+	})
+	if len(matches) == 0 || boundingBoxArea(largestUnmatched.BoundingBox) > boundingBoxArea(largestMatch.Face.BoundingBox) {
+		return false, 0, nil
+	}
+
+	similarity := aws.ToFloat32(largestMatch.Similarity)
+	return similarity >= threshold, similarity, nil
+}
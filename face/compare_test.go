@@ -0,0 +1,106 @@
+package face
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func compareMatch(bbox types.BoundingBox, similarity float32) types.CompareFacesMatch {
+	return types.CompareFacesMatch{Face: &types.ComparedFace{BoundingBox: &bbox}, Similarity: aws.Float32(similarity)}
+}
+
+// oneFace is a DetectFaces that finds a single face in any image
+func oneFace(*rekognition.DetectFacesInput) (*rekognition.DetectFacesOutput, error) {
+	return &rekognition.DetectFacesOutput{FaceDetails: []types.FaceDetail{faceDetail(boundingBox(0.25, 0.25, 0.5, 0.5))}}, nil
+}
+
+func TestAreSamePerson(t *testing.T) {
+	small, large := boundingBox(0, 0, 0.1, 0.1), boundingBox(0.5, 0.5, 0.4, 0.4)
+	tests := []struct {
+		name           string
+		resp           *rekognition.CompareFacesOutput
+		err            error
+		wantSame       bool
+		wantSimilarity float32
+		wantErr        error
+	}{
+		{
+			name:           "largest face matches",
+			resp:           &rekognition.CompareFacesOutput{FaceMatches: []types.CompareFacesMatch{compareMatch(small, 20), compareMatch(large, 97)}},
+			wantSame:       true,
+			wantSimilarity: 97,
+		},
+		{
+			name:           "largest face below threshold",
+			resp:           &rekognition.CompareFacesOutput{FaceMatches: []types.CompareFacesMatch{compareMatch(small, 99), compareMatch(large, 60)}},
+			wantSimilarity: 60,
+		},
+		{
+			name: "largest face unmatched",
+			resp: &rekognition.CompareFacesOutput{
+				FaceMatches:    []types.CompareFacesMatch{compareMatch(small, 99)},
+				UnmatchedFaces: []types.ComparedFace{{BoundingBox: &large}},
+			},
+		},
+		{
+			name:    "no face in b",
+			resp:    &rekognition.CompareFacesOutput{},
+			wantErr: ErrNoFaceDetected,
+		},
+		{
+			name:    "no face in a",
+			err:     awsOperationError("CompareFaces", "req-1", 400, &types.InvalidParameterException{Message: aws.String("There are no faces in the image.")}),
+			wantErr: ErrNoFaceDetected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeRekognition{
+				compareFaces: func(*rekognition.CompareFacesInput) (*rekognition.CompareFacesOutput, error) {
+					return tt.resp, tt.err
+				},
+				detectFaces: oneFace,
+			}
+			faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+			same, similarity, err := faceIndexer.AreSamePerson(context.TODO(), testJPEG(t, 100, 100), testJPEG(t, 100, 100), 90)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if same != tt.wantSame || similarity != tt.wantSimilarity {
+				t.Fatalf("got %v with similarity %v, want %v with %v", same, similarity, tt.wantSame, tt.wantSimilarity)
+			}
+		})
+	}
+}
+
+func TestAreSamePersonNoFace(t *testing.T) {
+	withFace, withoutFace := testJPEG(t, 100, 100), testJPEG(t, 120, 120)
+	fake := &fakeRekognition{
+		detectFaces: func(input *rekognition.DetectFacesInput) (*rekognition.DetectFacesOutput, error) {
+			if bytes.Equal(input.Image.Bytes, withoutFace) {
+				return &rekognition.DetectFacesOutput{}, nil
+			}
+			return oneFace(input)
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	_, _, err := faceIndexer.AreSamePerson(context.TODO(), withoutFace, withFace, 90)
+	if !errors.Is(err, ErrNoFaceInSource) || errors.Is(err, ErrNoFaceInTarget) || !errors.Is(err, ErrNoFaceDetected) {
+		t.Fatalf("got error %v, want %v", err, ErrNoFaceInSource)
+	}
+	_, _, err = faceIndexer.AreSamePerson(context.TODO(), withFace, withoutFace, 90)
+	if !errors.Is(err, ErrNoFaceInTarget) || errors.Is(err, ErrNoFaceInSource) {
+		t.Fatalf("got error %v, want %v", err, ErrNoFaceInTarget)
+	}
+	if got := fake.count("CompareFaces"); got != 0 {
+		t.Fatalf("got %d CompareFaces calls, want none", got)
+	}
+}
@@ -0,0 +1,14 @@
+package face
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// ContentHash returns the hex encoded SHA-256 of the image bytes, the hash passed to the
+// WithSeenContent callback. Callers record it once an image is indexed so unchanged photos
+// are skipped on the next ingestion.
+func ContentHash(image []byte) string {
+	sum := sha256.Sum256(image)
+	return hex.EncodeToString(sum[:])
+}
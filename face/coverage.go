@@ -0,0 +1,130 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+// CoverageReport is the outcome of CoverageReport: how many faces of a source collection,
+// e.g. enrolled people, appear in a target collection, e.g. an event's photos.
+type CoverageReport struct {
+	SourceFaces int `json:"sourceFaces"`
+	// CoveredFaces are the source faces with at least one match in the target
+	CoveredFaces int `json:"coveredFaces"`
+	// Matches are the target matches of each covered source face, by source FaceId
+	Matches map[string][]FaceMatchResult `json:"matches"`
+}
+
+// Coverage is the share (0-1) of source faces with at least one match, 0 for an empty source.
+func (c CoverageReport) Coverage() float64 {
+	if c.SourceFaces == 0 {
+		return 0
+	}
+	return float64(c.CoveredFaces) / float64(c.SourceFaces)
+}
+
+// CoverageReport searches every face of the source collection against the target collection, with
+// up to concurrency searches at a time, 1 when unset, and reports how many have a match with a
+// similarity of at least threshold. Rekognition can only search a FaceId within its own collection,
+// so each source face is cropped from its photo, fetched with fetchImage, and searched by image.
+// WithProgress reports each searched face. Faces whose search failed count as uncovered and are
+// reported in the returned error next to the partial report.
+func (r *rekognitionFaceIndexer) CoverageReport(ctx context.Context, sourceCollectionId string, targetCollectionId string, threshold float32, concurrency int, fetchImage ImageFetcher, opts ...CallOption) (CoverageReport, error) {
+	for _, collectionId := range []string{sourceCollectionId, targetCollectionId} {
+		if err := validateCollectionId(collectionId); err != nil {
+			return CoverageReport{}, err
+		}
+	}
+	o := newCallOptions(opts)
+
+	var faces []types.Face
+	err := r.forEachFace(ctx, sourceCollectionId, o, func(face types.Face) error {
+		faces = append(faces, face)
+		return nil
+	})
+	if err != nil {
+		return CoverageReport{}, fmt.Errorf("failed to report coverage: %w", err)
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		report = CoverageReport{SourceFaces: len(faces), Matches: make(map[string][]FaceMatchResult)}
+		done   int
+		errs   []error
+	)
+	work := make(chan types.Face)
+	for w := 0; w < min(max(concurrency, 1), len(faces)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for face := range work {
+				matches, err := r.searchStoredFace(ctx, face, targetCollectionId, threshold, fetchImage, o)
+
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("face %s: %w", aws.ToString(face.FaceId), err))
+				} else if len(matches) > 0 {
+					report.CoveredFaces++
+					report.Matches[aws.ToString(face.FaceId)] = matches
+				}
+				done++
+				if o.progress != nil {
+					o.progress(done, len(faces))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var ctxErr error
+	for _, face := range faces {
+		if ctxErr = ctx.Err(); ctxErr != nil {
+			break
+		}
+		work <- face
+	}
+	close(work)
+	wg.Wait()
+
+	if ctxErr != nil {
+		errs = append(errs, ctxErr)
+	}
+	if len(errs) > 0 {
+		return report, fmt.Errorf("failed to report coverage: %w", errors.Join(errs...))
+	}
+	return report, nil
+}
+
+// searchStoredFace searches a stored face of another collection against the collection, using
+// a crop of the photo it was indexed from, or the photo's largest face when it has no bounding box
+func (r *rekognitionFaceIndexer) searchStoredFace(ctx context.Context, face types.Face, collectionId string, threshold float32, fetchImage ImageFetcher, o callOptions) ([]FaceMatchResult, error) {
+	externalImageId := aws.ToString(face.ExternalImageId)
+	image, err := fetchImage(externalImageId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch image %s: %w", externalImageId, err)
+	}
+
+	if face.BoundingBox == nil {
+		if _, err := validateImage(image); err != nil {
+			return nil, err
+		}
+		resp, err := r.searchFacesByImage(ctx, &types.Image{Bytes: image}, collectionId, threshold, o)
+		if err != nil {
+			return nil, err
+		}
+		return faceMatchResults(resp.FaceMatches, o), nil
+	}
+
+	// Bounding boxes refer to the upright image, so rotate before cropping
+	img, format, err := decodeUpright(image)
+	if err != nil {
+		return nil, err
+	}
+	return r.searchFaceRegion(ctx, img, format, *face.BoundingBox, collectionId, threshold, o)
+}
@@ -0,0 +1,75 @@
+package face
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func TestCoverageReport(t *testing.T) {
+	var faces []types.Face
+	images := map[string][]byte{}
+	for i := 0; i < 4; i++ {
+		externalImageId := fmt.Sprintf("photo_%d", i)
+		faces = append(faces, types.Face{FaceId: aws.String(fmt.Sprintf("face-%d", i)), ExternalImageId: aws.String(externalImageId)})
+		images[externalImageId] = testJPEG(t, 100+10*i, 100)
+	}
+	// The photo of face-3 is gone, so its search fails
+	delete(images, "photo_3")
+
+	fake := &fakeRekognition{
+		listFaces: pagedListFaces(faces, 2),
+		searchFacesByImage: func(input *rekognition.SearchFacesByImageInput) (*rekognition.SearchFacesByImageOutput, error) {
+			if aws.ToString(input.CollectionId) != "event_1" || aws.ToFloat32(input.FaceMatchThreshold) != 90 {
+				return nil, fmt.Errorf("unexpected input %+v", input)
+			}
+			config, err := jpeg.DecodeConfig(bytes.NewReader(input.Image.Bytes))
+			if err != nil {
+				return nil, err
+			}
+			// Only the photo of face-1 has a match in the target
+			if config.Width != 110 {
+				return &rekognition.SearchFacesByImageOutput{}, nil
+			}
+			return &rekognition.SearchFacesByImageOutput{FaceMatches: []types.FaceMatch{faceMatch("target-1", "photo_9", 95)}}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+	fetchImage := func(externalImageId string) ([]byte, error) {
+		image, ok := images[externalImageId]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+		return image, nil
+	}
+
+	var progress []int
+	report, err := faceIndexer.CoverageReport(context.TODO(), "people", "event_1", 90, 2, fetchImage, WithProgress(func(done int, total int) {
+		if total != 4 {
+			t.Errorf("got total %d, want 4", total)
+		}
+		progress = append(progress, done)
+	}))
+	if err == nil {
+		t.Fatalf("got no error, want the failed search of face-3")
+	}
+	if report.SourceFaces != 4 || report.CoveredFaces != 1 || report.Coverage() != 0.25 {
+		t.Fatalf("got report %+v, want 1 of 4 faces covered", report)
+	}
+	if matches := report.Matches["face-1"]; len(matches) != 1 || matches[0].FaceId != "target-1" {
+		t.Fatalf("got matches %+v for face-1, want target-1", report.Matches)
+	}
+	if len(progress) != 4 || progress[3] != 4 {
+		t.Fatalf("got progress %v, want 4 calls ending at 4", progress)
+	}
+	if got := fake.count("SearchFacesByImage"); got != 3 {
+		t.Fatalf("got %d SearchFacesByImage calls, want 3", got)
+	}
+}
@@ -0,0 +1,101 @@
+package face
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+// CropFaceRegion crops img to the face described by a Rekognition bounding box.
+// The box is normalized (0-1) relative to the image, as Rekognition returns it.
+// It is grown around its center by scale (1 keeps the detected box, 1.5 adds
+// margin for hair and chin) and clamped to the image bounds. When the scaled
+// box falls outside the image it falls back to the unscaled box, and returns
+// ErrInvalidBoundingBox when neither overlaps the image.
+func CropFaceRegion(img image.Image, bbox types.BoundingBox, scale float64) (image.Image, error) {
+	if bbox.Left == nil || bbox.Top == nil || bbox.Width == nil || bbox.Height == nil {
+		return nil, fmt.Errorf("%w: missing coordinates", ErrInvalidBoundingBox)
+	}
+	rect, err := faceRegionRect(img.Bounds(), bbox, scale)
+	if err != nil {
+		return nil, err
+	}
+	return cropRect(img, rect), nil
+}
+
+// faceRegionRect is the rectangle of the image bounds CropFaceRegion crops for bbox
+func faceRegionRect(bounds image.Rectangle, bbox types.BoundingBox, scale float64) (image.Rectangle, error) {
+	if scale <= 0 {
+		scale = 1
+	}
+
+	rect := scaledRect(bounds, bbox, scale).Intersect(bounds)
+	if rect.Empty() {
+		// Fallback to the box Rekognition detected
+		rect = scaledRect(bounds, bbox, 1).Intersect(bounds)
+	}
+	if rect.Empty() {
+		return image.Rectangle{}, fmt.Errorf("%w: box %v is outside the %dx%d image", ErrInvalidBoundingBox, bboxString(bbox), bounds.Dx(), bounds.Dy())
+	}
+	return rect, nil
+}
+
+// CropPixelRegion crops img to rect, given in pixels of img as another detector would
+// report it, e.g. to mix its output with Rekognition's. rect is clamped to the image
+// bounds, and ErrInvalidBoundingBox is returned when it doesn't overlap the image.
+func CropPixelRegion(img image.Image, rect image.Rectangle) (image.Image, error) {
+	bounds := img.Bounds()
+	clamped := rect.Canon().Intersect(bounds)
+	if clamped.Empty() {
+		return nil, fmt.Errorf("%w: rectangle %v is outside the %v image", ErrInvalidBoundingBox, rect, bounds)
+	}
+	return cropRect(img, clamped), nil
+}
+
+// scaledRect converts the normalized box to pixel coordinates of bounds,
+// growing it around its center by scale
+func scaledRect(bounds image.Rectangle, bbox types.BoundingBox, scale float64) image.Rectangle {
+	width := float64(bounds.Dx())
+	height := float64(bounds.Dy())
+
+	boxWidth := float64(aws.ToFloat32(bbox.Width)) * width * scale
+	boxHeight := float64(aws.ToFloat32(bbox.Height)) * height * scale
+	centerX := (float64(aws.ToFloat32(bbox.Left)) + float64(aws.ToFloat32(bbox.Width))/2) * width
+	centerY := (float64(aws.ToFloat32(bbox.Top)) + float64(aws.ToFloat32(bbox.Height))/2) * height
+
+	return image.Rect(
+		bounds.Min.X+int(centerX-boxWidth/2),
+		bounds.Min.Y+int(centerY-boxHeight/2),
+		bounds.Min.X+int(centerX+boxWidth/2),
+		bounds.Min.Y+int(centerY+boxHeight/2),
+	)
+}
+
+// cropRect copies rect out of img into a new image whose origin is (0, 0)
+func cropRect(img image.Image, rect image.Rectangle) image.Image {
+	cropped := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(cropped, cropped.Bounds(), img, rect.Min, draw.Src)
+	return cropped
+}
+
+func bboxString(bbox types.BoundingBox) string {
+	return fmt.Sprintf("[left=%.3f top=%.3f width=%.3f height=%.3f]",
+		aws.ToFloat32(bbox.Left), aws.ToFloat32(bbox.Top), aws.ToFloat32(bbox.Width), aws.ToFloat32(bbox.Height))
+}
+
+// cropFace decodes the image upright, crops the face in bbox and encodes the crop
+// in the source format unless the call overrides it. The crop carries no EXIF.
+func cropFace(imageBytes []byte, bbox types.BoundingBox, scale float64, o callOptions) ([]byte, error) {
+	img, format, err := decodeUpright(imageBytes)
+	if err != nil {
+		return nil, err
+	}
+	cropped, err := CropFaceRegion(img, bbox, scale)
+	if err != nil {
+		return nil, err
+	}
+	return o.encodeCrop(cropped, format)
+}
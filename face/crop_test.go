@@ -0,0 +1,157 @@
+package face
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func boundingBox(left, top, width, height float32) types.BoundingBox {
+	return types.BoundingBox{
+		Left:   aws.Float32(left),
+		Top:    aws.Float32(top),
+		Width:  aws.Float32(width),
+		Height: aws.Float32(height),
+	}
+}
+
+func TestCropFaceRegion(t *testing.T) {
+	img := testImage(200, 100)
+
+	tests := []struct {
+		name     string
+		bbox     types.BoundingBox
+		scale    float64
+		wantSize image.Point
+		wantErr  error
+	}{
+		{name: "unscaled", bbox: boundingBox(0.25, 0.25, 0.5, 0.5), scale: 1, wantSize: image.Pt(100, 50)},
+		{name: "scaled", bbox: boundingBox(0.25, 0.25, 0.5, 0.5), scale: 1.5, wantSize: image.Pt(150, 75)},
+		{name: "clamped", bbox: boundingBox(0.8, 0.8, 0.4, 0.4), scale: 1, wantSize: image.Pt(40, 20)},
+		{name: "outside", bbox: boundingBox(1.5, 1.5, 0.2, 0.2), scale: 1, wantErr: ErrInvalidBoundingBox},
+		{name: "missing", bbox: types.BoundingBox{}, scale: 1, wantErr: ErrInvalidBoundingBox},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cropped, err := CropFaceRegion(img, tt.bbox, tt.scale)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := cropped.Bounds().Size(); got != tt.wantSize {
+				t.Fatalf("got size %v, want %v", got, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestCropPixelRegion(t *testing.T) {
+	img := testImage(200, 100)
+
+	tests := []struct {
+		name     string
+		rect     image.Rectangle
+		wantSize image.Point
+		wantErr  error
+	}{
+		{name: "inside", rect: image.Rect(10, 20, 60, 80), wantSize: image.Pt(50, 60)},
+		{name: "clamped", rect: image.Rect(150, -10, 250, 40), wantSize: image.Pt(50, 40)},
+		{name: "outside", rect: image.Rect(300, 300, 400, 400), wantErr: ErrInvalidBoundingBox},
+		{name: "empty", rect: image.Rect(10, 10, 10, 50), wantErr: ErrInvalidBoundingBox},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cropped, err := CropPixelRegion(img, tt.rect)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := cropped.Bounds().Size(); got != tt.wantSize {
+				t.Fatalf("got size %v, want %v", got, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestCropFacePreservesFormat(t *testing.T) {
+	bbox := boundingBox(0.25, 0.25, 0.5, 0.5)
+
+	tests := []struct {
+		name       string
+		source     []byte
+		opts       []CallOption
+		wantFormat string
+	}{
+		{name: "jpeg source", source: testJPEG(t, 100, 100), wantFormat: "jpeg"},
+		{name: "png source", source: testPNG(t, 100, 100), wantFormat: "png"},
+		{name: "png source as jpeg", source: testPNG(t, 100, 100), opts: []CallOption{WithOutputFormat(FormatJPEG)}, wantFormat: "jpeg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crop, err := cropFace(tt.source, bbox, 1, newCallOptions(tt.opts))
+			if err != nil {
+				t.Fatalf("error cropping face: %v", err)
+			}
+			_, format, err := image.DecodeConfig(bytes.NewReader(crop))
+			if err != nil {
+				t.Fatalf("error decoding crop: %v", err)
+			}
+			if format != tt.wantFormat {
+				t.Fatalf("got format %s, want %s", format, tt.wantFormat)
+			}
+		})
+	}
+}
+
+// hasExif reports whether JPEG bytes carry an APP1 Exif segment
+func hasExif(jpegBytes []byte) bool {
+	return bytes.Contains(jpegBytes, []byte("Exif\x00\x00"))
+}
+
+func TestCropsStripExif(t *testing.T) {
+	source := withExifOrientation(testJPEG(t, 200, 200), orientationRotate180)
+	if !hasExif(source) {
+		t.Fatal("test source has no exif")
+	}
+	bbox := boundingBox(0, 0, 1, 1)
+
+	crop, err := cropFace(source, bbox, 1, callOptions{})
+	if err != nil {
+		t.Fatalf("error cropping face: %v", err)
+	}
+	if hasExif(crop) {
+		t.Fatal("crop covering the whole image kept the source exif")
+	}
+
+	fake := &fakeRekognition{
+		detectFaces: func(*rekognition.DetectFacesInput) (*rekognition.DetectFacesOutput, error) {
+			return &rekognition.DetectFacesOutput{FaceDetails: []types.FaceDetail{faceDetail(bbox)}}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+	crops, err := faceIndexer.ExtractFaces(context.TODO(), source, 1)
+	if err != nil {
+		t.Fatalf("error extracting faces: %v", err)
+	}
+	if hasExif(crops[0].Crop) {
+		t.Fatal("extracted crop kept the source exif")
+	}
+
+	annotated, err := AnnotateFaces(source, []types.BoundingBox{bbox})
+	if err != nil {
+		t.Fatalf("error annotating faces: %v", err)
+	}
+	if hasExif(annotated) {
+		t.Fatal("annotated image kept the source exif")
+	}
+}
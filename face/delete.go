@@ -0,0 +1,69 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+	"github.com/samber/lo"
+)
+
+// deleteFacesBatchSize is the largest number of FaceIds DeleteFaces accepts per call
+const deleteFacesBatchSize = 4096
+
+// DeleteSummary is the outcome of a bulk delete.
+type DeleteSummary struct {
+	Requested   int          `json:"requested"`
+	Deleted     int          `json:"deleted"`
+	Failed      int          `json:"failed"`
+	FailedFaces []FailedFace `json:"failedFaces"`
+}
+
+// FailedFace is a face Rekognition didn't delete, with its reasons.
+type FailedFace struct {
+	FaceId  string                                 `json:"faceId"`
+	Reasons []types.UnsuccessfulFaceDeletionReason `json:"reasons"`
+}
+
+// String summarizes the delete on one line for logging
+func (s DeleteSummary) String() string {
+	return fmt.Sprintf("requested %d, deleted %d, failed %d", s.Requested, s.Deleted, s.Failed)
+}
+
+// DeleteFacebyFaceIds deletes the faces from the collection in batches of up to 4096 FaceIds.
+// Faces Rekognition couldn't delete are reported in the summary rather than as an error. With
+// WithIgnoreMissingCollection, a collection that doesn't exist counts as already cleaned up.
+func (r *rekognitionFaceIndexer) DeleteFacebyFaceIds(ctx context.Context, faceIds []string, collectionId string, opts ...CallOption) (DeleteSummary, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return DeleteSummary{}, err
+	}
+	o := newCallOptions(opts)
+	summary := DeleteSummary{Requested: len(faceIds)}
+	for _, batch := range lo.Chunk(faceIds, deleteFacesBatchSize) {
+		resp, err := invoke(ctx, r, "DeleteFaces", r.client.DeleteFaces, &rekognition.DeleteFacesInput{
+			CollectionId: aws.String(collectionId),
+			FaceIds:      batch,
+		}, o.apiOptions...)
+		if err != nil {
+			var notFound *types.ResourceNotFoundException
+			if o.ignoreMissingCollection && errors.As(err, &notFound) {
+				r.logger(ctx).Info("Collection does not exist, nothing left to delete", "collectionId", collectionId)
+				break
+			}
+			return summary, fmt.Errorf("failed to delete faces: %w", err)
+		}
+
+		summary.Deleted += len(resp.DeletedFaces)
+		for _, unsuccessful := range resp.UnsuccessfulFaceDeletions {
+			summary.FailedFaces = append(summary.FailedFaces, FailedFace{
+				FaceId:  aws.ToString(unsuccessful.FaceId),
+				Reasons: unsuccessful.Reasons,
+			})
+		}
+	}
+	summary.Failed = len(summary.FailedFaces)
+	return summary, nil
+}
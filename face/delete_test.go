@@ -0,0 +1,72 @@
+package face
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func TestDeleteFacebyFaceIds(t *testing.T) {
+	fake := &fakeRekognition{
+		deleteFaces: func(input *rekognition.DeleteFacesInput) (*rekognition.DeleteFacesOutput, error) {
+			resp := &rekognition.DeleteFacesOutput{}
+			for _, faceId := range input.FaceIds {
+				if faceId == "face-13" {
+					resp.UnsuccessfulFaceDeletions = append(resp.UnsuccessfulFaceDeletions, types.UnsuccessfulFaceDeletion{
+						FaceId:  aws.String(faceId),
+						Reasons: []types.UnsuccessfulFaceDeletionReason{types.UnsuccessfulFaceDeletionReasonFaceNotFound},
+					})
+					continue
+				}
+				resp.DeletedFaces = append(resp.DeletedFaces, faceId)
+			}
+			return resp, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	faceIds := make([]string, deleteFacesBatchSize+10)
+	for i := range faceIds {
+		faceIds[i] = fmt.Sprintf("face-%d", i)
+	}
+
+	summary, err := faceIndexer.DeleteFacebyFaceIds(context.TODO(), faceIds, "event_1")
+	if err != nil {
+		t.Fatalf("error deleting faces: %v", err)
+	}
+	if summary.Requested != len(faceIds) || summary.Deleted != len(faceIds)-1 || summary.Failed != 1 {
+		t.Fatalf("got summary %s", summary)
+	}
+	if summary.FailedFaces[0].FaceId != "face-13" {
+		t.Fatalf("got failed face %+v, want face-13", summary.FailedFaces[0])
+	}
+	if calls := fake.count("DeleteFaces"); calls != 2 {
+		t.Fatalf("DeleteFaces called %d times, want 2", calls)
+	}
+}
+
+func TestDeleteFacebyFaceIdsMissingCollection(t *testing.T) {
+	fake := &fakeRekognition{
+		deleteFaces: func(*rekognition.DeleteFacesInput) (*rekognition.DeleteFacesOutput, error) {
+			return nil, awsOperationError("DeleteFaces", "req-1", 400, &types.ResourceNotFoundException{})
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	ctx := context.TODO()
+	if _, err := faceIndexer.DeleteFacebyFaceIds(ctx, []string{"face-1"}, "deleted"); err == nil {
+		t.Fatal("expected an error for the missing collection by default")
+	}
+
+	summary, err := faceIndexer.DeleteFacebyFaceIds(ctx, []string{"face-1", "face-2"}, "deleted", WithIgnoreMissingCollection())
+	if err != nil {
+		t.Fatalf("error deleting from a missing collection: %v", err)
+	}
+	if summary.Requested != 2 || summary.Deleted != 0 || summary.Failed != 0 || len(summary.FailedFaces) != 0 {
+		t.Fatalf("got summary %s with failed faces %v, want nothing deleted and nothing failed", summary, summary.FailedFaces)
+	}
+}
@@ -0,0 +1,246 @@
+package face
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+	"github.com/samber/lo"
+)
+
+// LivenessHints are cheap DetectFaces heuristics about the most prominent face.
+// They are not liveness detection, just a gate before enrollment.
+type LivenessHints struct {
+	EyesOpen           bool    `json:"eyesOpen"`
+	EyesOpenConfidence float32 `json:"eyesOpenConfidence"`
+	Smiling            bool    `json:"smiling"`
+	SmileConfidence    float32 `json:"smileConfidence"`
+}
+
+// CheckLivenessHints reports whether the largest face in the image has its eyes open and is smiling
+func (r *rekognitionFaceIndexer) CheckLivenessHints(ctx context.Context, image []byte) (LivenessHints, error) {
+	faces, err := r.detectFaces(ctx, image, []types.Attribute{types.AttributeAll}, callOptions{})
+	if err != nil {
+		return LivenessHints{}, fmt.Errorf("failed to check liveness hints: %w", err)
+	}
+	if len(faces) == 0 {
+		return LivenessHints{}, fmt.Errorf("failed to check liveness hints: %w", ErrNoFaceDetected)
+	}
+
+	face := largestFace(faces)
+	var hints LivenessHints
+	if face.EyesOpen != nil {
+		hints.EyesOpen = face.EyesOpen.Value
+		hints.EyesOpenConfidence = aws.ToFloat32(face.EyesOpen.Confidence)
+	}
+	if face.Smile != nil {
+		hints.Smiling = face.Smile.Value
+		hints.SmileConfidence = aws.ToFloat32(face.Smile.Confidence)
+	}
+	return hints, nil
+}
+
+// FaceCount returns how many faces DetectFaces finds in the image, e.g. to reject a crowd
+// photo before an expensive index. It counts faces detected in this image, not faces stored
+// in a collection; that is the FaceCount of DescribeCollection. DetectFaces reports at most
+// the 100 largest faces, so larger crowds count as 100.
+func (r *rekognitionFaceIndexer) FaceCount(ctx context.Context, image []byte) (int, error) {
+	faces, err := r.detectFaces(ctx, image, nil, callOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count faces: %w", err)
+	}
+	return len(faces), nil
+}
+
+// FaceCrop is a detected face and its crop, encoded like the source image unless overridden.
+type FaceCrop struct {
+	BoundingBox types.BoundingBox `json:"boundingBox"`
+	Crop        []byte            `json:"crop"`
+}
+
+// ExtractFaces detects every face in the image and returns their crops ordered by
+// bounding-box area, largest (most prominent) first. Nothing is indexed or searched.
+// Crops are re-encoded and carry no EXIF metadata from the source.
+func (r *rekognitionFaceIndexer) ExtractFaces(ctx context.Context, image []byte, scale float64, opts ...CallOption) ([]FaceCrop, error) {
+	o := newCallOptions(opts)
+	faces, err := r.detectFaces(ctx, image, nil, o)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract faces: %w", err)
+	}
+	faces = lo.Filter(faces, func(face types.FaceDetail, _ int) bool {
+		return face.BoundingBox != nil
+	})
+	sort.SliceStable(faces, func(i, j int) bool {
+		return boundingBoxArea(faces[i].BoundingBox) > boundingBoxArea(faces[j].BoundingBox)
+	})
+
+	// Bounding boxes refer to the upright image, so rotate before cropping
+	img, format, err := decodeUpright(image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract faces: %w", err)
+	}
+
+	crops := make([]FaceCrop, 0, len(faces))
+	for _, face := range faces {
+		cropped, err := CropFaceRegion(img, *face.BoundingBox, scale)
+		if err != nil {
+			return nil, fmt.Errorf("failed to crop face %s: %w", bboxString(*face.BoundingBox), err)
+		}
+		crop, err := o.encodeCrop(cropped, format)
+		if err != nil {
+			return nil, err
+		}
+		crops = append(crops, FaceCrop{BoundingBox: *face.BoundingBox, Crop: crop})
+	}
+	return crops, nil
+}
+
+// DetectFacesWithBucket is DetectFaces for an image stored in S3. Rekognition reads the object
+// directly, so the image is never downloaded to this service.
+func (r *rekognitionFaceIndexer) DetectFacesWithBucket(ctx context.Context, s3Bucket string, s3Key string, attributes []types.Attribute) ([]types.FaceDetail, error) {
+	image, err := r.s3Image(ctx, s3Bucket, s3Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect faces: %w", err)
+	}
+
+	resp, err := invoke(ctx, r, "DetectFaces", r.client.DetectFaces, &rekognition.DetectFacesInput{
+		Image:      image,
+		Attributes: attributes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect faces: %w", err)
+	}
+	return resp.FaceDetails, nil
+}
+
+// detectFaces validates the image and returns the faces DetectFaces finds in it
+func (r *rekognitionFaceIndexer) detectFaces(ctx context.Context, image []byte, attributes []types.Attribute, o callOptions) ([]types.FaceDetail, error) {
+	// Reject images Rekognition can't read before making any call
+	if _, err := validateImage(image); err != nil {
+		return nil, err
+	}
+
+	// Retry once downscaled when the image is too large
+	resp, err := retryDownscaled(r.logger(ctx), image, func(imageBytes []byte) (*rekognition.DetectFacesOutput, error) {
+		return invoke(ctx, r, "DetectFaces", r.client.DetectFaces, &rekognition.DetectFacesInput{
+			Image:      &types.Image{Bytes: imageBytes},
+			Attributes: attributes,
+		}, o.apiOptions...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect faces: %w", err)
+	}
+	return resp.FaceDetails, nil
+}
+
+// largestFace returns the face with the biggest bounding box, the most prominent one
+func largestFace(faces []types.FaceDetail) types.FaceDetail {
+	return lo.MaxBy(faces, func(a, b types.FaceDetail) bool {
+		return boundingBoxArea(a.BoundingBox) > boundingBoxArea(b.BoundingBox)
+	})
+}
+
+func boundingBoxArea(bbox *types.BoundingBox) float32 {
+	if bbox == nil {
+		return 0
+	}
+	return aws.ToFloat32(bbox.Width) * aws.ToFloat32(bbox.Height)
+}
+
+// Demographics is the age range and gender Rekognition estimates for a face.
+type Demographics struct {
+	BoundingBox      types.BoundingBox `json:"boundingBox"`
+	AgeLow           int32             `json:"ageLow"`
+	AgeHigh          int32             `json:"ageHigh"`
+	Gender           string            `json:"gender"`
+	GenderConfidence float32           `json:"genderConfidence"`
+}
+
+// EstimateDemographics returns the estimated age range and gender of the most prominent face in the image
+func (r *rekognitionFaceIndexer) EstimateDemographics(ctx context.Context, image []byte) (int32, int32, string, float32, error) {
+	faces, err := r.detectFaces(ctx, image, []types.Attribute{types.AttributeAll}, callOptions{})
+	if err != nil {
+		return 0, 0, "", 0, fmt.Errorf("failed to estimate demographics: %w", err)
+	}
+	if len(faces) == 0 {
+		return 0, 0, "", 0, fmt.Errorf("failed to estimate demographics: %w", ErrNoFaceDetected)
+	}
+
+	d := demographics(largestFace(faces))
+	return d.AgeLow, d.AgeHigh, d.Gender, d.GenderConfidence, nil
+}
+
+// EstimateAllDemographics is EstimateDemographics for every face in the image, largest first
+func (r *rekognitionFaceIndexer) EstimateAllDemographics(ctx context.Context, image []byte) ([]Demographics, error) {
+	faces, err := r.detectFaces(ctx, image, []types.Attribute{types.AttributeAll}, callOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate demographics: %w", err)
+	}
+	sort.SliceStable(faces, func(i, j int) bool {
+		return boundingBoxArea(faces[i].BoundingBox) > boundingBoxArea(faces[j].BoundingBox)
+	})
+	return lo.Map(faces, func(face types.FaceDetail, _ int) Demographics {
+		return demographics(face)
+	}), nil
+}
+
+func demographics(face types.FaceDetail) Demographics {
+	var d Demographics
+	if face.BoundingBox != nil {
+		d.BoundingBox = *face.BoundingBox
+	}
+	if face.AgeRange != nil {
+		d.AgeLow = aws.ToInt32(face.AgeRange.Low)
+		d.AgeHigh = aws.ToInt32(face.AgeRange.High)
+	}
+	if face.Gender != nil {
+		d.Gender = string(face.Gender.Value)
+		d.GenderConfidence = aws.ToFloat32(face.Gender.Confidence)
+	}
+	return d
+}
+
+// FaceEmotions are the emotions Rekognition reads on a detected face.
+type FaceEmotions struct {
+	BoundingBox types.BoundingBox `json:"boundingBox"`
+	// Emotions are ranked by confidence, highest first
+	Emotions []Emotion `json:"emotions"`
+}
+
+// Emotion is an emotion and how confident Rekognition is that the face shows it.
+type Emotion struct {
+	Type       types.EmotionName `json:"type"`
+	Confidence float32           `json:"confidence"`
+}
+
+// DetectEmotions returns the ranked emotions of every face in the image, largest first, e.g.
+// to aggregate the mood of a crowd. Nothing is indexed or searched.
+func (r *rekognitionFaceIndexer) DetectEmotions(ctx context.Context, image []byte) ([]FaceEmotions, error) {
+	faces, err := r.detectFaces(ctx, image, []types.Attribute{types.AttributeAll}, callOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect emotions: %w", err)
+	}
+	sort.SliceStable(faces, func(i, j int) bool {
+		return boundingBoxArea(faces[i].BoundingBox) > boundingBoxArea(faces[j].BoundingBox)
+	})
+	return lo.Map(faces, func(face types.FaceDetail, _ int) FaceEmotions {
+		return faceEmotions(face)
+	}), nil
+}
+
+func faceEmotions(face types.FaceDetail) FaceEmotions {
+	e := FaceEmotions{Emotions: make([]Emotion, 0, len(face.Emotions))}
+	if face.BoundingBox != nil {
+		e.BoundingBox = *face.BoundingBox
+	}
+	for _, emotion := range face.Emotions {
+		e.Emotions = append(e.Emotions, Emotion{Type: emotion.Type, Confidence: aws.ToFloat32(emotion.Confidence)})
+	}
+	sort.SliceStable(e.Emotions, func(i, j int) bool {
+		return e.Emotions[i].Confidence > e.Emotions[j].Confidence
+	})
+	return e
+}
@@ -0,0 +1,208 @@
+package face
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/jpeg"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func faceDetail(bbox types.BoundingBox) types.FaceDetail {
+	return types.FaceDetail{BoundingBox: &bbox, Confidence: aws.Float32(99.9)}
+}
+
+func TestCheckLivenessHints(t *testing.T) {
+	small := faceDetail(boundingBox(0.1, 0.1, 0.1, 0.1))
+	small.EyesOpen = &types.EyeOpen{Value: false, Confidence: aws.Float32(90)}
+	large := faceDetail(boundingBox(0.4, 0.4, 0.3, 0.3))
+	large.EyesOpen = &types.EyeOpen{Value: true, Confidence: aws.Float32(98)}
+	large.Smile = &types.Smile{Value: true, Confidence: aws.Float32(87)}
+
+	fake := &fakeRekognition{
+		detectFaces: func(input *rekognition.DetectFacesInput) (*rekognition.DetectFacesOutput, error) {
+			if len(input.Attributes) != 1 || input.Attributes[0] != types.AttributeAll {
+				t.Errorf("got attributes %v, want ALL", input.Attributes)
+			}
+			return &rekognition.DetectFacesOutput{FaceDetails: []types.FaceDetail{small, large}}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	hints, err := faceIndexer.CheckLivenessHints(context.TODO(), testJPEG(t, 100, 100))
+	if err != nil {
+		t.Fatalf("error checking liveness hints: %v", err)
+	}
+	want := LivenessHints{EyesOpen: true, EyesOpenConfidence: 98, Smiling: true, SmileConfidence: 87}
+	if hints != want {
+		t.Fatalf("got %+v, want %+v", hints, want)
+	}
+}
+
+func TestCheckLivenessHintsNoFace(t *testing.T) {
+	faceIndexer := &rekognitionFaceIndexer{client: &fakeRekognition{}}
+
+	_, err := faceIndexer.CheckLivenessHints(context.TODO(), testJPEG(t, 100, 100))
+	if !errors.Is(err, ErrNoFaceDetected) {
+		t.Fatalf("got error %v, want %v", err, ErrNoFaceDetected)
+	}
+}
+
+func TestFaceCount(t *testing.T) {
+	fake := &fakeRekognition{
+		detectFaces: func(input *rekognition.DetectFacesInput) (*rekognition.DetectFacesOutput, error) {
+			if len(input.Attributes) != 0 {
+				t.Errorf("got attributes %v, want the default set", input.Attributes)
+			}
+			return &rekognition.DetectFacesOutput{FaceDetails: []types.FaceDetail{
+				faceDetail(boundingBox(0.1, 0.1, 0.1, 0.1)),
+				faceDetail(boundingBox(0.4, 0.4, 0.1, 0.1)),
+				faceDetail(boundingBox(0.7, 0.7, 0.1, 0.1)),
+			}}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	count, err := faceIndexer.FaceCount(context.TODO(), testJPEG(t, 100, 100))
+	if err != nil {
+		t.Fatalf("error counting faces: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("got %d faces, want 3", count)
+	}
+}
+
+func TestDetectFacesWithBucket(t *testing.T) {
+	fake := &fakeRekognition{
+		detectFaces: func(input *rekognition.DetectFacesInput) (*rekognition.DetectFacesOutput, error) {
+			if input.Image.Bytes != nil {
+				t.Errorf("got image bytes, want only an S3 object")
+			}
+			if got := input.Image.S3Object; got == nil || aws.ToString(got.Bucket) != "bucket" || aws.ToString(got.Name) != "key.jpg" {
+				t.Errorf("got S3 object %+v, want bucket/key.jpg", got)
+			}
+			if len(input.Attributes) != 1 || input.Attributes[0] != types.AttributeAll {
+				t.Errorf("got attributes %v, want ALL", input.Attributes)
+			}
+			return &rekognition.DetectFacesOutput{FaceDetails: []types.FaceDetail{
+				faceDetail(boundingBox(0.1, 0.1, 0.1, 0.1)),
+				faceDetail(boundingBox(0.4, 0.4, 0.1, 0.1)),
+			}}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	faces, err := faceIndexer.DetectFacesWithBucket(context.TODO(), "bucket", "key.jpg", []types.Attribute{types.AttributeAll})
+	if err != nil {
+		t.Fatalf("error detecting faces: %v", err)
+	}
+	if len(faces) != 2 {
+		t.Fatalf("got %d faces, want 2", len(faces))
+	}
+}
+
+func TestExtractFaces(t *testing.T) {
+	fake := &fakeRekognition{
+		detectFaces: func(*rekognition.DetectFacesInput) (*rekognition.DetectFacesOutput, error) {
+			return &rekognition.DetectFacesOutput{FaceDetails: []types.FaceDetail{
+				faceDetail(boundingBox(0, 0, 0.1, 0.1)),
+				faceDetail(boundingBox(0.5, 0.5, 0.4, 0.4)),
+				faceDetail(boundingBox(0.2, 0.2, 0.2, 0.2)),
+			}}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	crops, err := faceIndexer.ExtractFaces(context.TODO(), testJPEG(t, 200, 200), 1)
+	if err != nil {
+		t.Fatalf("error extracting faces: %v", err)
+	}
+	wantWidths := []int{80, 40, 20}
+	if len(crops) != len(wantWidths) {
+		t.Fatalf("got %d crops, want %d", len(crops), len(wantWidths))
+	}
+	for i, crop := range crops {
+		config, err := jpeg.DecodeConfig(bytes.NewReader(crop.Crop))
+		if err != nil {
+			t.Fatalf("error decoding crop %d: %v", i, err)
+		}
+		if config.Width != wantWidths[i] {
+			t.Fatalf("crop %d is %dpx wide, want %d", i, config.Width, wantWidths[i])
+		}
+	}
+}
+
+func TestEstimateDemographics(t *testing.T) {
+	small := faceDetail(boundingBox(0.1, 0.1, 0.1, 0.1))
+	small.AgeRange = &types.AgeRange{Low: aws.Int32(8), High: aws.Int32(12)}
+	small.Gender = &types.Gender{Value: types.GenderTypeFemale, Confidence: aws.Float32(80)}
+	large := faceDetail(boundingBox(0.4, 0.4, 0.3, 0.3))
+	large.AgeRange = &types.AgeRange{Low: aws.Int32(25), High: aws.Int32(33)}
+	large.Gender = &types.Gender{Value: types.GenderTypeMale, Confidence: aws.Float32(99)}
+
+	fake := &fakeRekognition{
+		detectFaces: func(*rekognition.DetectFacesInput) (*rekognition.DetectFacesOutput, error) {
+			return &rekognition.DetectFacesOutput{FaceDetails: []types.FaceDetail{small, large}}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	ctx := context.TODO()
+	ageLow, ageHigh, gender, genderConfidence, err := faceIndexer.EstimateDemographics(ctx, testJPEG(t, 100, 100))
+	if err != nil {
+		t.Fatalf("error estimating demographics: %v", err)
+	}
+	if ageLow != 25 || ageHigh != 33 || gender != "Male" || genderConfidence != 99 {
+		t.Fatalf("got %d-%d %s (%.0f), want 25-33 Male (99)", ageLow, ageHigh, gender, genderConfidence)
+	}
+
+	all, err := faceIndexer.EstimateAllDemographics(ctx, testJPEG(t, 100, 100))
+	if err != nil {
+		t.Fatalf("error estimating demographics: %v", err)
+	}
+	if len(all) != 2 || all[0].AgeLow != 25 || all[1].Gender != "Female" {
+		t.Fatalf("got %+v, want the large face then the small one", all)
+	}
+}
+
+func TestDetectEmotions(t *testing.T) {
+	small := faceDetail(boundingBox(0.1, 0.1, 0.1, 0.1))
+	small.Emotions = []types.Emotion{
+		{Type: types.EmotionNameCalm, Confidence: aws.Float32(60)},
+		{Type: types.EmotionNameSad, Confidence: aws.Float32(30)},
+	}
+	large := faceDetail(boundingBox(0.4, 0.4, 0.3, 0.3))
+	large.Emotions = []types.Emotion{
+		{Type: types.EmotionNameCalm, Confidence: aws.Float32(5)},
+		{Type: types.EmotionNameHappy, Confidence: aws.Float32(92)},
+		{Type: types.EmotionNameSurprised, Confidence: aws.Float32(3)},
+	}
+	var attributes []types.Attribute
+	fake := &fakeRekognition{
+		detectFaces: func(input *rekognition.DetectFacesInput) (*rekognition.DetectFacesOutput, error) {
+			attributes = input.Attributes
+			return &rekognition.DetectFacesOutput{FaceDetails: []types.FaceDetail{small, large}}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	faces, err := faceIndexer.DetectEmotions(context.TODO(), testJPEG(t, 100, 100))
+	if err != nil {
+		t.Fatalf("error detecting emotions: %v", err)
+	}
+	if !reflect.DeepEqual(attributes, []types.Attribute{types.AttributeAll}) {
+		t.Fatalf("got attributes %v, want ALL", attributes)
+	}
+	want := []FaceEmotions{
+		{BoundingBox: *large.BoundingBox, Emotions: []Emotion{{types.EmotionNameHappy, 92}, {types.EmotionNameCalm, 5}, {types.EmotionNameSurprised, 3}}},
+		{BoundingBox: *small.BoundingBox, Emotions: []Emotion{{types.EmotionNameCalm, 60}, {types.EmotionNameSad, 30}}},
+	}
+	if !reflect.DeepEqual(faces, want) {
+		t.Fatalf("got %+v, want %+v", faces, want)
+	}
+}
@@ -0,0 +1,84 @@
+package face
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// imageExtensions are the file extensions IndexDirectory picks up, compared lowercased
+var imageExtensions = []string{".jpg", ".jpeg", ".png"}
+
+// IndexDirectory walks dir, including its subdirectories, and indexes every JPEG and PNG file
+// into the collection with up to concurrency files at a time, 1 when unset. The ExternalImageId of
+// each file is idFromFilename(path), or the file name without its extension when idFromFilename is
+// nil; either way it is normalized with NormalizeExternalImageId. Files are read as they are
+// indexed, so the whole directory is never held in memory. Like IndexFacesBatch, per-file failures,
+// including read errors, are reported in the matching IndexResult, in walk order, and joined in
+// the returned error, unless WithErrorPolicy(FailFast) stops at the first one.
+func (r *rekognitionFaceIndexer) IndexDirectory(ctx context.Context, dir string, collectionId string, idFromFilename func(path string) string, concurrency int, opts ...CallOption) ([]IndexResult, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return nil, err
+	}
+	if idFromFilename == nil {
+		idFromFilename = idFromBaseName
+	}
+
+	paths, err := imagePaths(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory %s: %w", dir, err)
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	// Ensure the collection once so the workers don't race to create it
+	if err := r.createCollectionIfNotExists(ctx, r.client, collectionId, newCallOptions(opts)); err != nil {
+		return nil, fmt.Errorf("failed to ensure collection exists: %w", err)
+	}
+
+	externalImageId := func(i int) string { return idFromFilename(paths[i]) }
+	results, failed, err := indexConcurrently(ctx, len(paths), concurrency, newCallOptions(opts).errorPolicy, externalImageId, func(ctx context.Context, i int) IndexResult {
+		return r.indexFile(ctx, paths[i], idFromFilename(paths[i]), collectionId, opts)
+	})
+	if failed != nil {
+		return results, fmt.Errorf("index directory failed: %w", failed)
+	}
+	if err != nil {
+		return results, fmt.Errorf("index directory cancelled: %w", err)
+	}
+	return results, nil
+}
+
+func (r *rekognitionFaceIndexer) indexFile(ctx context.Context, path string, externalImageId string, collectionId string, opts []CallOption) IndexResult {
+	image, err := os.ReadFile(path)
+	if err != nil {
+		return IndexResult{ExternalImageId: NormalizeExternalImageId(externalImageId), Err: fmt.Errorf("failed to read %s: %w", path, err)}
+	}
+	return r.indexBatchItem(ctx, BatchItem{Image: image, ExternalImageId: externalImageId}, collectionId, opts)
+}
+
+// imagePaths returns the paths of the JPEG and PNG files under dir, in lexical order
+func imagePaths(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type().IsRegular() && slices.Contains(imageExtensions, strings.ToLower(filepath.Ext(path))) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// idFromBaseName is the default ExternalImageId of a file: its name without the extension
+func idFromBaseName(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
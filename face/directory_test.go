@@ -0,0 +1,50 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndexDirectory(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"b.jpg":               testJPEG(t, 100, 100),
+		"nested/a photo.JPEG": testJPEG(t, 100, 100),
+		"c.png":               []byte("corrupt"),
+		"notes.txt":           []byte("not an image"),
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("error creating directory: %v", err)
+		}
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatalf("error writing %s: %v", name, err)
+		}
+	}
+	fake := &fakeRekognition{}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	results, err := faceIndexer.IndexDirectory(context.TODO(), dir, "event_1", nil, 2)
+	if !errors.Is(err, ErrUnsupportedImageFormat) {
+		t.Fatalf("got error %v, want the failure of c.png", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3: %+v", len(results), results)
+	}
+	if results[0].ExternalImageId != "b" || results[0].Err != nil || len(results[0].FaceIds) != 1 {
+		t.Fatalf("got %+v for b.jpg", results[0])
+	}
+	if results[1].ExternalImageId != "c" || !errors.Is(results[1].Err, ErrUnsupportedImageFormat) {
+		t.Fatalf("got %+v for c.png, want %v", results[1], ErrUnsupportedImageFormat)
+	}
+	if results[2].ExternalImageId != "a_20photo" || results[2].Err != nil {
+		t.Fatalf("got %+v for nested/a photo.JPEG", results[2])
+	}
+	if got := fake.count("IndexFaces"); got != 2 {
+		t.Fatalf("got %d IndexFaces calls, want 2", got)
+	}
+}
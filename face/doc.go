@@ -0,0 +1,7 @@
+// Package face indexes and searches faces with Amazon Rekognition.
+//
+// The result types returned by the Face interface carry JSON tags with stable, camelCase
+// field names, so they can be marshaled as-is by an HTTP API. Bounding boxes keep the shape
+// of the Rekognition API (Left, Top, Width and Height, normalized 0-1) and crops are
+// base64-encoded.
+package face
@@ -0,0 +1,86 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// maxImageBytes is the largest image Rekognition accepts as bytes
+	maxImageBytes = 5 * 1024 * 1024
+	// defaultDownloadTimeout bounds an image download when WithDownloadTimeout isn't set
+	defaultDownloadTimeout = 30 * time.Second
+)
+
+// IndexFaceFromURL downloads the image at imageURL, e.g. a signed HTTPS URL, and indexes it like
+// IndexFace. The download uses the client and timeout set by WithHTTPClient and WithDownloadTimeout.
+// A non-200 response returns ErrDownloadFailed and an image over 5MB ErrImageTooLarge.
+func (r *rekognitionFaceIndexer) IndexFaceFromURL(ctx context.Context, imageURL string, externalImageId string, collectionId string, opts ...CallOption) error {
+	if err := validateCollectionId(collectionId); err != nil {
+		return err
+	}
+	image, err := r.downloadImage(ctx, imageURL)
+	if err != nil {
+		return fmt.Errorf("failed to index face from url: %w", err)
+	}
+
+	_, err = r.indexFaceBytes(ctx, image, externalImageId, collectionId, newCallOptions(opts))
+	return err
+}
+
+// downloadImage fetches the image bytes, refusing responses larger than Rekognition accepts
+func (r *rekognitionFaceIndexer) downloadImage(ctx context.Context, imageURL string) ([]byte, error) {
+	parsed, err := url.Parse(imageURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid url: %v", ErrDownloadFailed, err)
+	}
+	// Signed URLs carry credentials in the query, keep them out of errors
+	location := parsed.Scheme + "://" + parsed.Host + parsed.Path
+
+	timeout := r.options.downloadTimeout
+	if timeout <= 0 {
+		timeout = defaultDownloadTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrDownloadFailed, location, err)
+	}
+	client := r.options.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// The url.Error repeats the full URL
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("%w: %s: %v", ErrDownloadFailed, location, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s returned %s", ErrDownloadFailed, location, resp.Status)
+	}
+	if resp.ContentLength > maxImageBytes {
+		return nil, fmt.Errorf("%w: %s is %d bytes, more than %d", ErrImageTooLarge, location, resp.ContentLength, maxImageBytes)
+	}
+
+	image, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrDownloadFailed, location, err)
+	}
+	if len(image) > maxImageBytes {
+		return nil, fmt.Errorf("%w: %s is more than %d bytes", ErrImageTooLarge, location, maxImageBytes)
+	}
+	return image, nil
+}
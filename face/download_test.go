@@ -0,0 +1,72 @@
+package face
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+)
+
+func TestIndexFaceFromURL(t *testing.T) {
+	imageBytes := testJPEG(t, 100, 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/photo.jpg":
+			w.Write(imageBytes)
+		case "/huge.jpg":
+			w.Write(bytes.Repeat([]byte{0}, maxImageBytes+1))
+		case "/slow.jpg":
+			time.Sleep(200 * time.Millisecond)
+			w.Write(imageBytes)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var indexed []byte
+	fake := &fakeRekognition{}
+	fake.indexFaces = func(input *rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+		indexed = input.Image.Bytes
+		return (&fakeRekognition{}).IndexFaces(context.TODO(), input)
+	}
+	faceIndexer := &rekognitionFaceIndexer{
+		client:  fake,
+		options: newOptions([]Option{WithHTTPClient(server.Client()), WithDownloadTimeout(100 * time.Millisecond)}),
+	}
+
+	ctx := context.TODO()
+	if err := faceIndexer.IndexFaceFromURL(ctx, server.URL+"/photo.jpg?signature=secret", "photo_1", "event_1"); err != nil {
+		t.Fatalf("error indexing face from url: %v", err)
+	}
+	if !bytes.Equal(indexed, imageBytes) {
+		t.Fatal("indexed bytes differ from the downloaded image")
+	}
+
+	tests := []struct {
+		path    string
+		wantErr error
+	}{
+		{"/missing.jpg?signature=secret", ErrDownloadFailed},
+		{"/huge.jpg", ErrImageTooLarge},
+		{"/slow.jpg?signature=secret", ErrDownloadFailed},
+	}
+	for _, tt := range tests {
+		err := faceIndexer.IndexFaceFromURL(ctx, server.URL+tt.path, "photo_1", "event_1")
+		if !errors.Is(err, tt.wantErr) {
+			t.Fatalf("%s: got error %v, want %v", tt.path, err, tt.wantErr)
+		}
+		if strings.Contains(err.Error(), "secret") {
+			t.Fatalf("%s: error %q leaks the url signature", tt.path, err)
+		}
+	}
+	if got := fake.count("IndexFaces"); got != 1 {
+		t.Fatalf("got %d IndexFaces calls, want 1", got)
+	}
+}
@@ -0,0 +1,138 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+// DuplicateScan is the progress of a duplicate scan over a collection. It only holds plain
+// fields so it can be persisted, e.g. as JSON, and passed back to ScanDuplicateFaces to
+// resume an interrupted scan.
+type DuplicateScan struct {
+	CollectionId string `json:"collectionId"`
+	// Threshold is the similarity above which two faces are duplicates, Rekognition's default when 0
+	Threshold float32 `json:"threshold"`
+	// Searched are the FaceIds already searched against the collection
+	Searched []string `json:"searched"`
+	// Pairs are the duplicate FaceIds found so far
+	Pairs [][2]string `json:"pairs"`
+}
+
+// FindDuplicateFaces searches every face of the collection against the collection and
+// returns clusters of FaceIds whose similarity exceeds threshold, i.e. likely enrollments
+// of the same person. It makes one SearchFaces call per face; use ScanDuplicateFaces to
+// be able to resume after a cancellation.
+func (r *rekognitionFaceIndexer) FindDuplicateFaces(ctx context.Context, collectionId string, threshold float32, opts ...CallOption) ([][]string, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return nil, err
+	}
+	return r.ScanDuplicateFaces(ctx, &DuplicateScan{CollectionId: collectionId, Threshold: threshold}, opts...)
+}
+
+// ScanDuplicateFaces runs or resumes a duplicate scan, see FindDuplicateFaces. Faces
+// listed in scan.Searched are skipped and scan is updated after every search, so when the
+// context is cancelled or a call fails, the same scan can be passed again to carry on.
+func (r *rekognitionFaceIndexer) ScanDuplicateFaces(ctx context.Context, scan *DuplicateScan, opts ...CallOption) ([][]string, error) {
+	if err := validateCollectionId(scan.CollectionId); err != nil {
+		return nil, err
+	}
+	o := newCallOptions(opts)
+	o.faceMatchThreshold = scan.Threshold
+	if o.maxFaces <= 0 {
+		o.maxFaces = maxSearchFaces
+	}
+
+	var faceIds []string
+	err := r.forEachFace(ctx, scan.CollectionId, o, func(face types.Face) error {
+		faceIds = append(faceIds, aws.ToString(face.FaceId))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find duplicate faces: %w", err)
+	}
+	// Search in a stable order so progress is meaningful across runs
+	slices.Sort(faceIds)
+
+	searched := make(map[string]bool, len(scan.Searched))
+	for _, faceId := range scan.Searched {
+		searched[faceId] = true
+	}
+	for _, faceId := range faceIds {
+		if searched[faceId] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("failed to find duplicate faces: %w", err)
+		}
+		matches, err := r.searchDuplicates(ctx, scan.CollectionId, faceId, o)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find duplicate faces: %w", err)
+		}
+		for _, match := range matches {
+			// Each pair is found from both sides, keep it once
+			if !searched[match] {
+				scan.Pairs = append(scan.Pairs, [2]string{faceId, match})
+			}
+		}
+		searched[faceId] = true
+		scan.Searched = append(scan.Searched, faceId)
+	}
+	return duplicateClusters(scan.Pairs), nil
+}
+
+// searchDuplicates returns the FaceIds of the other faces matching faceId. A face deleted
+// since the collection was listed has no duplicates.
+func (r *rekognitionFaceIndexer) searchDuplicates(ctx context.Context, collectionId string, faceId string, o callOptions) ([]string, error) {
+	resp, err := invoke(ctx, r, "SearchFaces", r.client.SearchFaces, searchFacesInput(collectionId, faceId, o), o.apiOptions...)
+	if err != nil {
+		var invalidParamErr *types.InvalidParameterException
+		if errors.As(err, &invalidParamErr) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var matches []string
+	for _, match := range resp.FaceMatches {
+		if match.Face != nil && aws.ToString(match.Face.FaceId) != faceId {
+			matches = append(matches, aws.ToString(match.Face.FaceId))
+		}
+	}
+	return matches, nil
+}
+
+// duplicateClusters groups the pairs into connected clusters, each sorted, ordered by their first FaceId
+func duplicateClusters(pairs [][2]string) [][]string {
+	parent := make(map[string]string)
+	var root func(faceId string) string
+	root = func(faceId string) string {
+		if p, ok := parent[faceId]; ok && p != faceId {
+			parent[faceId] = root(p)
+			return parent[faceId]
+		}
+		parent[faceId] = faceId
+		return faceId
+	}
+	for _, pair := range pairs {
+		parent[root(pair[0])] = root(pair[1])
+	}
+
+	members := make(map[string][]string)
+	for faceId := range parent {
+		members[root(faceId)] = append(members[root(faceId)], faceId)
+	}
+	clusters := make([][]string, 0, len(members))
+	for _, cluster := range members {
+		slices.Sort(cluster)
+		clusters = append(clusters, cluster)
+	}
+	slices.SortFunc(clusters, func(a, b []string) int {
+		return strings.Compare(a[0], b[0])
+	})
+	return clusters
+}
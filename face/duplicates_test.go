@@ -0,0 +1,102 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func TestFindDuplicateFaces(t *testing.T) {
+	var faces []types.Face
+	for i := 0; i < 6; i++ {
+		faces = append(faces, types.Face{FaceId: aws.String(fmt.Sprintf("face-%d", i))})
+	}
+	// face-0, face-2 and face-4 are the same person, as are face-1 and face-5
+	duplicates := map[string][]string{
+		"face-0": {"face-2"},
+		"face-2": {"face-0", "face-4"},
+		"face-4": {"face-2"},
+		"face-1": {"face-5"},
+		"face-5": {"face-1"},
+	}
+	fake := &fakeRekognition{
+		listFaces: pagedListFaces(faces, 4),
+		searchFaces: func(input *rekognition.SearchFacesInput) (*rekognition.SearchFacesOutput, error) {
+			if aws.ToFloat32(input.FaceMatchThreshold) != 95 || aws.ToInt32(input.MaxFaces) != maxSearchFaces {
+				t.Errorf("got threshold %v and max faces %v, want 95 and %d", aws.ToFloat32(input.FaceMatchThreshold), aws.ToInt32(input.MaxFaces), maxSearchFaces)
+			}
+			var matches []types.FaceMatch
+			for _, faceId := range duplicates[aws.ToString(input.FaceId)] {
+				matches = append(matches, types.FaceMatch{Face: &types.Face{FaceId: aws.String(faceId)}, Similarity: aws.Float32(98)})
+			}
+			return &rekognition.SearchFacesOutput{FaceMatches: matches}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	clusters, err := faceIndexer.FindDuplicateFaces(context.TODO(), "event_1", 95)
+	if err != nil {
+		t.Fatalf("error finding duplicates: %v", err)
+	}
+	want := [][]string{{"face-0", "face-2", "face-4"}, {"face-1", "face-5"}}
+	if !reflect.DeepEqual(clusters, want) {
+		t.Fatalf("got %v, want %v", clusters, want)
+	}
+	if calls := fake.count("SearchFaces"); calls != len(faces) {
+		t.Fatalf("SearchFaces called %d times, want %d", calls, len(faces))
+	}
+}
+
+func TestScanDuplicateFacesResumes(t *testing.T) {
+	faces := []types.Face{{FaceId: aws.String("face-a")}, {FaceId: aws.String("face-b")}, {FaceId: aws.String("face-c")}}
+	throttled := true
+	var searched []string
+	fake := &fakeRekognition{
+		listFaces: pagedListFaces(faces, 10),
+		searchFaces: func(input *rekognition.SearchFacesInput) (*rekognition.SearchFacesOutput, error) {
+			faceId := aws.ToString(input.FaceId)
+			if faceId == "face-c" && throttled {
+				return nil, awsOperationError("SearchFaces", "req-1", 400, &types.ThrottlingException{})
+			}
+			searched = append(searched, faceId)
+			if faceId == "face-a" {
+				return &rekognition.SearchFacesOutput{FaceMatches: []types.FaceMatch{{Face: &types.Face{FaceId: aws.String("face-c")}}}}, nil
+			}
+			return &rekognition.SearchFacesOutput{}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	scan := &DuplicateScan{CollectionId: "event_1"}
+	if _, err := faceIndexer.ScanDuplicateFaces(context.TODO(), scan); err == nil {
+		t.Fatal("expected the throttled search to fail the scan")
+	}
+	if want := []string{"face-a", "face-b"}; !reflect.DeepEqual(scan.Searched, want) {
+		t.Fatalf("got searched %v, want %v", scan.Searched, want)
+	}
+
+	throttled = false
+	clusters, err := faceIndexer.ScanDuplicateFaces(context.TODO(), scan)
+	if err != nil {
+		t.Fatalf("error resuming scan: %v", err)
+	}
+	if want := [][]string{{"face-a", "face-c"}}; !reflect.DeepEqual(clusters, want) {
+		t.Fatalf("got %v, want %v", clusters, want)
+	}
+	if want := []string{"face-a", "face-b", "face-c"}; !reflect.DeepEqual(searched, want) {
+		t.Fatalf("searched %v, want each face once", searched)
+	}
+
+	// A cancelled context stops before any search
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := faceIndexer.FindDuplicateFaces(ctx, "event_1", 0); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+}
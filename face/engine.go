@@ -2,10 +2,9 @@ package face
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"io"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/rekognition"
@@ -15,239 +14,484 @@ import (
 )
 
 type Face interface {
-	IndexFace(ctx context.Context, image []byte, imageID string, eventID string) error
-	SearchAndIndexSelfieFace(ctx context.Context, imageSelfie []byte, eventID string) (string, []string, error)
-	SearchFacebyFaceId(ctx context.Context, imageSelfieId string, eventID string) ([]string, error)
-	IndexFaceWithBucket(ctx context.Context, s3Bucket string, s3Key string, imageID string, eventID string) error
-	SearchFaceWithBucket(ctx context.Context, s3Bucket string, s3Key string, collectionId string) ([]string, error)
+	IndexFace(ctx context.Context, image []byte, imageID string, eventID string, opts ...CallOption) error
+	SearchAndIndexSelfieFace(ctx context.Context, imageSelfie []byte, eventID string, opts ...CallOption) (string, []string, error)
+	SearchAndIndexSelfieFaceWithCrop(ctx context.Context, imageSelfie []byte, collectionId string, opts ...CallOption) (SelfieResult, error)
+	SearchSelfieFaceWithCrop(ctx context.Context, imageSelfie []byte, collectionId string, opts ...CallOption) (SelfieResult, error)
+	SearchFacebyFaceId(ctx context.Context, imageSelfieId string, eventID string, opts ...CallOption) ([]string, error)
+	SearchManyByFaceIds(ctx context.Context, faceIds []string, collectionId string, concurrency int, opts ...CallOption) (map[string][]string, error)
+	IndexFaceWithBucket(ctx context.Context, s3Bucket string, s3Key string, imageID string, eventID string, opts ...CallOption) error
+	SearchFaceWithBucket(ctx context.Context, s3Bucket string, s3Key string, collectionId string, opts ...CallOption) ([]string, error)
+	SearchFaceMatchesWithBucket(ctx context.Context, s3Bucket string, s3Key string, collectionId string, opts ...CallOption) ([]FaceMatchResult, error)
+	SearchPhotoMatchesWithBucket(ctx context.Context, s3Bucket string, s3Key string, collectionId string, opts ...CallOption) ([]FaceMatchResult, error)
+	CreateUser(ctx context.Context, collectionId string, userId string) error
+	AssociateFaces(ctx context.Context, collectionId string, userId string, faceIds []string, userMatchThreshold float32) (AssociateFacesResult, error)
+	EstimateCollectionStorage(ctx context.Context, collectionId string) (faceCount int64, approxBytes int64, err error)
+	DescribeCollection(ctx context.Context, collectionId string) (CollectionInfo, error)
+	EnsureCollection(ctx context.Context, collectionId string) (string, error)
+	WarmCollection(ctx context.Context, collectionId string) error
+	AssertCollectionAccessible(ctx context.Context, collectionId string) error
+	SearchFaceAcrossCollections(ctx context.Context, image []byte, collectionIds []string, threshold float32, opts ...CallOption) (map[string]CollectionSearchResult, error)
+	CheckLivenessHints(ctx context.Context, image []byte) (LivenessHints, error)
+	CreateLivenessSession(ctx context.Context, opts LivenessSessionOptions) (sessionId string, err error)
+	GetLivenessSessionResults(ctx context.Context, sessionId string) (confidence float32, referenceImage []byte, err error)
+	IndexFaceAndCrop(ctx context.Context, image []byte, externalImageId string, collectionId string, scale float64, opts ...CallOption) (faceId string, crop []byte, err error)
+	FaceExists(ctx context.Context, collectionId string, faceId string) (bool, error)
+	FaceCount(ctx context.Context, image []byte) (int, error)
+	DetectFacesWithBucket(ctx context.Context, s3Bucket string, s3Key string, attributes []types.Attribute) ([]types.FaceDetail, error)
+	ExtractFaces(ctx context.Context, image []byte, scale float64, opts ...CallOption) ([]FaceCrop, error)
+	IndexFacesBatch(ctx context.Context, collectionId string, items []BatchItem, opts BatchOptions, callOpts ...CallOption) ([]IndexResult, error)
+	IndexDirectory(ctx context.Context, dir string, collectionId string, idFromFilename func(path string) string, concurrency int, opts ...CallOption) ([]IndexResult, error)
+	ListFacesPage(ctx context.Context, collectionId string, nextToken string, pageSize int32) (faces []types.Face, next string, err error)
+	ListFacesByExternalImageId(ctx context.Context, collectionId string, externalImageId string) ([]types.Face, error)
+	ExportCollection(ctx context.Context, collectionId string, w io.Writer) error
+	ImportCollection(ctx context.Context, collectionId string, r io.Reader, fetchImage ImageFetcher, opts ...CallOption) error
+	DeleteFacebyFaceIds(ctx context.Context, faceIds []string, collectionId string, opts ...CallOption) (DeleteSummary, error)
+	IndexFaceInRegion(ctx context.Context, image []byte, region types.BoundingBox, externalImageId string, collectionId string, opts ...CallOption) ([]IndexedFace, error)
+	IndexFaceFromURL(ctx context.Context, imageURL string, externalImageId string, collectionId string, opts ...CallOption) error
+	IndexSingleFaceOnly(ctx context.Context, image []byte, externalImageId string, collectionId string, opts ...CallOption) error
+	ReplaceFace(ctx context.Context, image []byte, externalImageId string, collectionId string, opts ...CallOption) (newFaceId string, deletedFaceIds []string, err error)
+	IndexFaceWithQualityGate(ctx context.Context, image []byte, externalImageId string, collectionId string, minSharpness float32, minBrightness float32, opts ...CallOption) (faceId string, err error)
+	IndexFaceDetailed(ctx context.Context, image []byte, externalImageId string, collectionId string, opts ...CallOption) (IndexFaceResult, error)
+	IndexFaceRaw(ctx context.Context, image []byte, externalImageId string, collectionId string, opts ...CallOption) (*rekognition.IndexFacesOutput, error)
+	SearchFacesByImageRaw(ctx context.Context, image []byte, collectionId string, opts ...CallOption) (*rekognition.SearchFacesByImageOutput, error)
+	AreSamePerson(ctx context.Context, a []byte, b []byte, threshold float32) (same bool, similarity float32, err error)
+	EstimateDemographics(ctx context.Context, image []byte) (ageLow int32, ageHigh int32, gender string, genderConfidence float32, err error)
+	EstimateAllDemographics(ctx context.Context, image []byte) ([]Demographics, error)
+	DetectEmotions(ctx context.Context, image []byte) ([]FaceEmotions, error)
+	FaceLandmarks(ctx context.Context, image []byte, opts ...CallOption) ([]Landmark, error)
+	AlignedFaceCrop(ctx context.Context, image []byte, scale float64, opts ...CallOption) ([]byte, error)
+	CropStoredFace(ctx context.Context, collectionId string, faceId string, fetchImage ImageFetcher, opts ...CallOption) ([]byte, error)
+	SearchSelfieFace(ctx context.Context, image []byte, collectionId string, opts ...CallOption) (matches []FaceMatchResult, searchedBox types.BoundingBox, crop []byte, err error)
+	SearchGroupPhoto(ctx context.Context, image []byte, collectionId string, opts ...CallOption) (GroupSearchResult, error)
+	SearchFaceInRegion(ctx context.Context, image []byte, region types.BoundingBox, collectionId string, opts ...CallOption) (RegionSearchResult, error)
+	FindDuplicateFaces(ctx context.Context, collectionId string, threshold float32, opts ...CallOption) ([][]string, error)
+	CoverageReport(ctx context.Context, sourceCollectionId string, targetCollectionId string, threshold float32, concurrency int, fetchImage ImageFetcher, opts ...CallOption) (CoverageReport, error)
+	ScanDuplicateFaces(ctx context.Context, scan *DuplicateScan, opts ...CallOption) ([][]string, error)
 }
 
+// rekognitionAPI is the subset of the Rekognition client used by the indexer.
+// *rekognition.Client satisfies it.
+type rekognitionAPI interface {
+	AssociateFaces(ctx context.Context, params *rekognition.AssociateFacesInput, optFns ...func(*rekognition.Options)) (*rekognition.AssociateFacesOutput, error)
+	CompareFaces(ctx context.Context, params *rekognition.CompareFacesInput, optFns ...func(*rekognition.Options)) (*rekognition.CompareFacesOutput, error)
+	CreateCollection(ctx context.Context, params *rekognition.CreateCollectionInput, optFns ...func(*rekognition.Options)) (*rekognition.CreateCollectionOutput, error)
+	CreateFaceLivenessSession(ctx context.Context, params *rekognition.CreateFaceLivenessSessionInput, optFns ...func(*rekognition.Options)) (*rekognition.CreateFaceLivenessSessionOutput, error)
+	CreateUser(ctx context.Context, params *rekognition.CreateUserInput, optFns ...func(*rekognition.Options)) (*rekognition.CreateUserOutput, error)
+	DeleteFaces(ctx context.Context, params *rekognition.DeleteFacesInput, optFns ...func(*rekognition.Options)) (*rekognition.DeleteFacesOutput, error)
+	DescribeCollection(ctx context.Context, params *rekognition.DescribeCollectionInput, optFns ...func(*rekognition.Options)) (*rekognition.DescribeCollectionOutput, error)
+	DetectFaces(ctx context.Context, params *rekognition.DetectFacesInput, optFns ...func(*rekognition.Options)) (*rekognition.DetectFacesOutput, error)
+	GetFaceLivenessSessionResults(ctx context.Context, params *rekognition.GetFaceLivenessSessionResultsInput, optFns ...func(*rekognition.Options)) (*rekognition.GetFaceLivenessSessionResultsOutput, error)
+	IndexFaces(ctx context.Context, params *rekognition.IndexFacesInput, optFns ...func(*rekognition.Options)) (*rekognition.IndexFacesOutput, error)
+	ListFaces(ctx context.Context, params *rekognition.ListFacesInput, optFns ...func(*rekognition.Options)) (*rekognition.ListFacesOutput, error)
+	SearchFaces(ctx context.Context, params *rekognition.SearchFacesInput, optFns ...func(*rekognition.Options)) (*rekognition.SearchFacesOutput, error)
+	SearchFacesByImage(ctx context.Context, params *rekognition.SearchFacesByImageInput, optFns ...func(*rekognition.Options)) (*rekognition.SearchFacesByImageOutput, error)
+}
+
+// rekognitionFaceIndexer is safe for concurrent use by multiple goroutines.
+// The AWS client is concurrency-safe, and any mutable state kept by the
+// indexer (such as the collection cache) is guarded by its own lock.
 type rekognitionFaceIndexer struct {
-	client *rekognition.Client
+	client      rekognitionAPI
+	options     options
+	collections collectionCache
+}
+
+func NewRekognitionFaceIndexer(client *rekognition.Client, opts ...Option) Face {
+	return &rekognitionFaceIndexer{client: client, options: newOptions(opts)}
 }
 
-func NewRekognitionFaceIndexer(client *rekognition.Client) Face {
-	return &rekognitionFaceIndexer{client: client}
+// defaultExternalImageId generates a random UUID based ExternalImageId
+func defaultExternalImageId(collectionId string) string {
+	return fmt.Sprintf("%s_%s", uuid.New().String(), collectionId)
+}
+
+// newExternalImageId generates an ExternalImageId with the configured generator
+func (r *rekognitionFaceIndexer) newExternalImageId(collectionId string) string {
+	if r.options.externalImageIdGenerator != nil {
+		return r.options.externalImageIdGenerator(collectionId)
+	}
+	return defaultExternalImageId(collectionId)
 }
 
 // Function to create a collection if it doesn't exist
-func (r *rekognitionFaceIndexer) createCollectionIfNotExists(ctx context.Context, rekognitionClient *rekognition.Client, collectionId string) error {
-	// Check if the collection exists
-	_, err := rekognitionClient.DescribeCollection(ctx, &rekognition.DescribeCollectionInput{
-		CollectionId: aws.String(collectionId),
-	})
+func (r *rekognitionFaceIndexer) createCollectionIfNotExists(ctx context.Context, rekognitionClient rekognitionAPI, collectionId string, o callOptions) error {
+	_, err := r.ensureCollectionVersion(ctx, rekognitionClient, collectionId, o)
+	return err
+}
+
+// ensureCollectionVersion makes sure the collection exists and returns its face model version
+func (r *rekognitionFaceIndexer) ensureCollectionVersion(ctx context.Context, rekognitionClient rekognitionAPI, collectionId string, o callOptions) (string, error) {
+	// Skip the round trip when we already know the collection exists
+	if version, ok := r.collections.faceModelVersion(collectionId); ok {
+		return version, nil
+	}
 
-	// If the collection does not exist, create it
+	// Concurrent first indexes into a new collection share a single check and create,
+	// made with the context of the first caller
+	version, err, _ := r.collections.ensuring.Do(collectionId, func() (any, error) {
+		return r.ensureCollection(ctx, rekognitionClient, collectionId, o)
+	})
 	if err != nil {
-		fmt.Printf("Collection %s does not exist. Creating a new collection...\n", collectionId)
-		_, err := rekognitionClient.CreateCollection(ctx, &rekognition.CreateCollectionInput{
+		return "", err
+	}
+	return version.(string), nil
+}
+
+// ensureCollection describes the collection and creates it when it doesn't exist. The collection
+// is only remembered when its face model version is the one pinned with WithFaceModelVersion.
+func (r *rekognitionFaceIndexer) ensureCollection(ctx context.Context, rekognitionClient rekognitionAPI, collectionId string, o callOptions) (string, error) {
+	// Check if the collection exists
+	describeResp, err := invoke(ctx, r, "DescribeCollection", rekognitionClient.DescribeCollection, &rekognition.DescribeCollectionInput{
+		CollectionId: aws.String(collectionId),
+	}, o.apiOptions...)
+
+	var version string
+	if err == nil {
+		version = aws.ToString(describeResp.FaceModelVersion)
+	} else {
+		// If the collection does not exist, create it
+		r.logger(ctx).Info("Collection does not exist, creating a new collection", "collectionId", collectionId)
+		createResp, err := invoke(ctx, r, "CreateCollection", rekognitionClient.CreateCollection, &rekognition.CreateCollectionInput{
 			CollectionId: aws.String(collectionId),
-		})
+		}, o.apiOptions...)
 		if err != nil {
 			var rae *types.ResourceAlreadyExistsException
-			if errors.As(err, &rae) {
-				log.Printf("Collection %s already exists, skip error while failed create it.\n", collectionId)
-				return nil
-			} else {
-				return fmt.Errorf("eror is not ResourceAlreadyExistsException failed to create collection: %v", err)
+			if !errors.As(err, &rae) {
+				return "", fmt.Errorf("eror is not ResourceAlreadyExistsException failed to create collection: %w", err)
+			}
+			r.logger(ctx).Info("Collection already exists, skip error while failed create it", "collectionId", collectionId)
+			// Another caller created it in the meantime, its version is only needed to check the pin
+			if r.options.faceModelVersion != "" {
+				describeResp, err := invoke(ctx, r, "DescribeCollection", rekognitionClient.DescribeCollection, &rekognition.DescribeCollectionInput{
+					CollectionId: aws.String(collectionId),
+				}, o.apiOptions...)
+				if err != nil {
+					return "", fmt.Errorf("failed to describe collection: %w", err)
+				}
+				version = aws.ToString(describeResp.FaceModelVersion)
 			}
+		} else {
+			version = aws.ToString(createResp.FaceModelVersion)
+			r.logger(ctx).Info("Collection created successfully", "collectionId", collectionId, "faceModelVersion", version)
 		}
-		fmt.Printf("Collection %s created successfully.\n", collectionId)
 	}
 
-	return nil
+	if pinned := r.options.faceModelVersion; pinned != "" && version != pinned {
+		return "", fmt.Errorf("%w: collection %s uses face model %q, want %q", ErrFaceModelVersionMismatch, collectionId, version, pinned)
+	}
+	r.collections.add(collectionId, version)
+	return version, nil
+}
+
+// EnsureCollection creates the collection when it doesn't exist and returns its face model
+// version. With WithFaceModelVersion, a collection on another version fails with
+// ErrFaceModelVersionMismatch, so environments defaulting to different models are caught.
+func (r *rekognitionFaceIndexer) EnsureCollection(ctx context.Context, collectionId string) (string, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return "", err
+	}
+	version, err := r.ensureCollectionVersion(ctx, r.client, collectionId, callOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to ensure collection exists: %w", err)
+	}
+	return version, nil
 }
 
 // IndexFace Implementation of IndexFace method in Face interface
-func (r *rekognitionFaceIndexer) IndexFace(ctx context.Context, imageBytes []byte, externalImageId string, collectionId string) error {
+func (r *rekognitionFaceIndexer) IndexFace(ctx context.Context, imageBytes []byte, externalImageId string, collectionId string, opts ...CallOption) error {
+	if err := validateCollectionId(collectionId); err != nil {
+		return err
+	}
+
+	_, err := r.indexFaceBytes(ctx, imageBytes, externalImageId, collectionId, newCallOptions(opts))
+	return err
+}
+
+// indexFaceBytes validates the image bytes and indexes the faces found in them
+func (r *rekognitionFaceIndexer) indexFaceBytes(ctx context.Context, imageBytes []byte, externalImageId string, collectionId string, o callOptions) (*rekognition.IndexFacesOutput, error) {
+	resp, _, err := r.indexFaceBytesHashed(ctx, imageBytes, externalImageId, collectionId, o)
+	return resp, err
+}
+
+// indexFaceBytesHashed is indexFaceBytes also returning the ContentHash of the image. The hash is
+// only computed, once, for WithSeenContent, and is empty otherwise.
+func (r *rekognitionFaceIndexer) indexFaceBytesHashed(ctx context.Context, imageBytes []byte, externalImageId string, collectionId string, o callOptions) (*rekognition.IndexFacesOutput, string, error) {
+	// Reject images Rekognition can't read before making any call
+	if _, err := validateImage(imageBytes); err != nil {
+		return nil, "", fmt.Errorf("failed to index face: %w", err)
+	}
+
+	// Skip content the caller already indexed, e.g. an unchanged photo of a re-ingested gallery
+	var hash string
+	if o.seenContent != nil {
+		hash = ContentHash(imageBytes)
+		if o.seenContent(hash) {
+			r.logger(ctx).Info("Image content already indexed, skip indexing", "externalImageId", externalImageId, "contentHash", hash)
+			return nil, hash, fmt.Errorf("skipped indexing face: %w: content %s", ErrAlreadyIndexed, hash)
+		}
+	}
+
+	resp, err := r.indexFaces(ctx, &types.Image{Bytes: imageBytes}, externalImageId, collectionId, o)
+	return resp, hash, err
+}
+
+// indexFaces ensures the collection exists and indexes the faces found in image
+func (r *rekognitionFaceIndexer) indexFaces(ctx context.Context, image *types.Image, externalImageId string, collectionId string, o callOptions) (*rekognition.IndexFacesOutput, error) {
+	externalImageId = NormalizeExternalImageId(externalImageId)
+	if err := validateExternalImageId(externalImageId); err != nil {
+		return nil, fmt.Errorf("failed to index face: %w", err)
+	}
 
 	// First, ensure the collection exists
-	err := r.createCollectionIfNotExists(ctx, r.client, collectionId)
+	err := r.createCollectionIfNotExists(ctx, r.client, collectionId, o)
 	if err != nil {
-		return fmt.Errorf("failed to ensure collection exists: %v", err)
+		return nil, fmt.Errorf("failed to ensure collection exists: %w", err)
+	}
+
+	// Skip images already indexed under this ExternalImageId, e.g. by a retried upload
+	if o.idempotent {
+		existing, err := r.listFacesByExternalImageId(ctx, collectionId, externalImageId, o)
+		if err != nil {
+			return nil, fmt.Errorf("failed to index face: %w", err)
+		}
+		if len(existing) > 0 {
+			r.logger(ctx).Info("Face already indexed, skip indexing", "externalImageId", externalImageId, "faces", len(existing))
+			return &rekognition.IndexFacesOutput{
+				FaceRecords: lo.Map(existing, func(face types.Face, _ int) types.FaceRecord {
+					return types.FaceRecord{Face: &face}
+				}),
+			}, nil
+		}
+	}
+
+	image, err = r.uploadImage(image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to index face: %w", err)
 	}
 
 	// Prepare the input for the IndexFaces API
 	input := &rekognition.IndexFacesInput{
-		CollectionId:    aws.String(collectionId),
-		Image:           &types.Image{Bytes: imageBytes},
-		ExternalImageId: aws.String(externalImageId),
+		CollectionId:        aws.String(collectionId),
+		Image:               image,
+		ExternalImageId:     aws.String(externalImageId),
+		DetectionAttributes: o.detectionAttributes,
 	}
 
-	// Call the IndexFaces API
-	resp, err := r.client.IndexFaces(ctx, input)
+	// Call the IndexFaces API, retrying once downscaled when the image bytes are too large
+	indexCall := func(imageBytes []byte) (*rekognition.IndexFacesOutput, error) {
+		input.Image = withBytes(image, imageBytes)
+		return invoke(ctx, r, "IndexFaces", r.client.IndexFaces, input, o.apiOptions...)
+	}
+	resp, err := retryDownscaled(r.logger(ctx), image.Bytes, indexCall)
+
+	// Every attribute of a large crowd can make the call time out, so retry once with the defaults
+	if err != nil && ctx.Err() == nil && isTimeout(err) && lo.Contains(input.DetectionAttributes, types.AttributeAll) {
+		r.logger(ctx).Warn("IndexFaces with ALL attributes timed out, retry once with DEFAULT attributes", "externalImageId", externalImageId)
+		input.DetectionAttributes = []types.Attribute{types.AttributeDefault}
+		resp, err = retryDownscaled(r.logger(ctx), image.Bytes, indexCall)
+		if err == nil {
+			resp.ResultMetadata.Set(attributesDowngradedKey{}, true)
+		}
+	}
 	if err != nil {
-		return fmt.Errorf("failed to index face: %v", err)
+		return nil, fmt.Errorf("failed to index face: %w", err)
+	}
+
+	// Take back the faces detected with less confidence than the caller accepts
+	if o.minDetectionConfidence > 0 {
+		if err := r.dropLowConfidenceFaces(ctx, resp, collectionId, o); err != nil {
+			return nil, fmt.Errorf("failed to index face: %w", err)
+		}
+	}
+
+	// Nothing enrolled, e.g. the quality filter rejected every face
+	if len(resp.FaceRecords) == 0 {
+		return nil, fmt.Errorf("failed to index face: %w", newUnindexedFacesError(resp.UnindexedFaces))
 	}
 
 	// Output the result
-	fmt.Printf("Successfully indexed face for ExternalImageId: %s\n", externalImageId)
+	logger := r.logger(ctx)
+	logger.Info("Successfully indexed face", "externalImageId", externalImageId)
 	for _, faceRecord := range resp.FaceRecords {
-		fmt.Printf("FaceId: %s, Confidence: %f\n", *faceRecord.Face.FaceId, *faceRecord.Face.Confidence)
+		var confidence any = "unknown"
+		if c, ok := faceConfidence(faceRecord); ok {
+			confidence = c
+		}
+		logger.Info("Indexed face", "faceId", aws.ToString(faceRecord.Face.FaceId), "confidence", confidence)
 	}
 
-	return nil
+	return resp, nil
 }
 
 // SearchFace Implementation of SearchFace method in Face interface
-func (r *rekognitionFaceIndexer) SearchAndIndexSelfieFace(ctx context.Context, imageSelfie []byte, collectionId string) (string, []string, error) {
+func (r *rekognitionFaceIndexer) SearchAndIndexSelfieFace(ctx context.Context, imageSelfie []byte, collectionId string, opts ...CallOption) (string, []string, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return "", nil, err
+	}
+
+	o := newCallOptions(opts)
+	selfie, err := r.searchAndIndexSelfie(ctx, imageSelfie, collectionId, o)
+	if err != nil {
+		return "", nil, err
+	}
+	return selfie.faceId, selfie.matches, noMatchError(len(selfie.matches), o)
+}
+
+// indexedSelfie is a selfie indexed and searched by searchAndIndexSelfie
+type indexedSelfie struct {
+	faceId      string
+	boundingBox types.BoundingBox
+	matches     []string
+}
 
-	// Generate a random UUID as ExternalImageId
-	externalImageId := fmt.Sprintf("%s_%s", uuid.New().String(), collectionId)
+// searchAndIndexSelfie indexes the selfie, waits until it is searchable and searches it against the collection
+func (r *rekognitionFaceIndexer) searchAndIndexSelfie(ctx context.Context, imageSelfie []byte, collectionId string, o callOptions) (indexedSelfie, error) {
+	// Reject images Rekognition can't read before making any call
+	if _, err := validateImage(imageSelfie); err != nil {
+		return indexedSelfie{}, fmt.Errorf("search face failed: %w", err)
+	}
+
+	// Generate the ExternalImageId for the selfie, a random UUID by default
+	externalImageId := NormalizeExternalImageId(r.newExternalImageId(collectionId))
+	if err := validateExternalImageId(externalImageId); err != nil {
+		return indexedSelfie{}, fmt.Errorf("search face failed: %w", err)
+	}
+
+	// Rotate and shrink the selfie like any other upload
+	image, err := r.uploadImage(&types.Image{Bytes: imageSelfie})
+	if err != nil {
+		return indexedSelfie{}, fmt.Errorf("search face failed: %w", err)
+	}
 
 	// Index the input selfie
 	inputIndexSelfie := &rekognition.IndexFacesInput{
-		CollectionId:    aws.String(collectionId),
-		Image:           &types.Image{Bytes: imageSelfie},
-		ExternalImageId: aws.String(externalImageId),
+		CollectionId:        aws.String(collectionId),
+		Image:               image,
+		ExternalImageId:     aws.String(externalImageId),
+		DetectionAttributes: o.detectionAttributes,
 	}
-	// Call the IndexFaces API
-	resp, err := r.client.IndexFaces(ctx, inputIndexSelfie)
+	// Call the IndexFaces API, retrying once downscaled when the image is too large
+	resp, err := retryDownscaled(r.logger(ctx), image.Bytes, func(imageBytes []byte) (*rekognition.IndexFacesOutput, error) {
+		inputIndexSelfie.Image = &types.Image{Bytes: imageBytes}
+		return invoke(ctx, r, "IndexFaces", r.client.IndexFaces, inputIndexSelfie, o.apiOptions...)
+	})
 	if err != nil {
-		return "", nil, fmt.Errorf("search face failed: error when try to index selfie face: %v", err)
+		return indexedSelfie{}, fmt.Errorf("search face failed: error when try to index selfie face: %w", err)
 	}
 
 	// Check if a face was detected and indexed
 	if len(resp.FaceRecords) == 0 {
-		return "", nil, fmt.Errorf("search face failed: no face detected in the image")
+		return indexedSelfie{}, fmt.Errorf("search face failed: no face detected in the image")
 	}
 
 	// Get the FaceId of the first indexed face
-	faceId := *resp.FaceRecords[0].Face.FaceId
-	fmt.Printf("Successfully Indexed FaceId: %s, ExternalImageId: %s\n", faceId, externalImageId)
+	face := indexedFace(resp.FaceRecords[0])
+	r.logger(ctx).Info("Successfully indexed selfie", "faceId", face.FaceId, "externalImageId", externalImageId)
 
-	externalImageIdResult, err := r.SearchFacebyFaceId(ctx, faceId, collectionId)
+	// The selfie may not be searchable right away, so back off until it is
+	searchResp, err := r.waitForFaceSearchable(ctx, collectionId, face.FaceId, r.faceSearchableMaxWait(), o)
 	if err != nil {
-		return "", nil, fmt.Errorf("search Face Failed: error when try to find selfie in collection: %v", err)
+		return indexedSelfie{}, fmt.Errorf("search Face Failed: error when try to find selfie in collection: %w", err)
 	}
-	return faceId, externalImageIdResult, nil
-}
+	externalImageIdResult := matchedExternalImageIds(searchResp.FaceMatches, o)
 
-// IndexFaceWithBucket Implementation of IndexFace method for S3 image input
-func (r *rekognitionFaceIndexer) IndexFaceWithBucket(ctx context.Context, s3Bucket string, s3Key string, externalImageId string, collectionId string) error {
-	// First, ensure the collection exists
-	err := r.createCollectionIfNotExists(ctx, r.client, collectionId)
-	if err != nil {
-		return fmt.Errorf("failed to ensure collection exists: %v", err)
+	// The selfie itself is indexed, so don't report its own id as a matched photo
+	if !o.includeSearchedFace {
+		externalImageIdResult = lo.Without(externalImageIdResult, externalImageId)
 	}
+	return indexedSelfie{faceId: face.FaceId, boundingBox: face.BoundingBox, matches: externalImageIdResult}, nil
+}
 
-	// Prepare the input for the IndexFaces API using S3Object
-	input := &rekognition.IndexFacesInput{
-		CollectionId: aws.String(collectionId),
-		Image: &types.Image{
-			S3Object: &types.S3Object{
-				Bucket: aws.String(s3Bucket),
-				Name:   aws.String(s3Key),
-			},
-		},
-		ExternalImageId: aws.String(externalImageId),
+// IndexFaceWithBucket Implementation of IndexFace method for S3 image input
+func (r *rekognitionFaceIndexer) IndexFaceWithBucket(ctx context.Context, s3Bucket string, s3Key string, externalImageId string, collectionId string, opts ...CallOption) error {
+	if err := validateCollectionId(collectionId); err != nil {
+		return err
 	}
-
-	// Call the IndexFaces API
-	resp, err := r.client.IndexFaces(ctx, input)
+	image, err := r.s3Image(ctx, s3Bucket, s3Key)
 	if err != nil {
-		return fmt.Errorf("failed to index face: %v", err)
-	}
-
-	// Output the result
-	fmt.Printf("Successfully indexed face for ExternalImageId: %s\n", externalImageId)
-	for _, faceRecord := range resp.FaceRecords {
-		fmt.Printf("FaceId: %s, Confidence: %f\n", *faceRecord.Face.FaceId, *faceRecord.Face.Confidence)
+		return fmt.Errorf("failed to index face: %w", err)
 	}
 
-	return nil
+	_, err = r.indexFaces(ctx, image, externalImageId, collectionId, newCallOptions(opts))
+	return err
 }
 
 // SearchFaceWithBucket Implementation of SearchFace method for S3 image input
-func (r *rekognitionFaceIndexer) SearchFaceWithBucket(ctx context.Context, s3Bucket string, s3Key string, collectionId string) ([]string, error) {
-	// Prepare the input for the SearchFacesByImage API using S3Object
-	input := &rekognition.SearchFacesByImageInput{
-		CollectionId: aws.String(collectionId),
-		Image: &types.Image{
-			S3Object: &types.S3Object{
-				Bucket: aws.String(s3Bucket),
-				Name:   aws.String(s3Key),
-			},
-		},
+func (r *rekognitionFaceIndexer) SearchFaceWithBucket(ctx context.Context, s3Bucket string, s3Key string, collectionId string, opts ...CallOption) ([]string, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return nil, err
 	}
-
-	// Call the SearchFacesByImage API
-	resp, err := r.client.SearchFacesByImage(ctx, input)
+	o := newCallOptions(opts)
+	resp, err := r.searchFacesByBucket(ctx, s3Bucket, s3Key, collectionId, o)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search face by image: %v", err)
+		return nil, err
 	}
 
-	// Use a slice to store ExternalImageIds
-	var externalImageIds []string
+	matches := matchedExternalImageIds(resp.FaceMatches, o)
+	return matches, noMatchError(len(matches), o)
+}
 
-	for _, match := range resp.FaceMatches {
-		if match.Face.ExternalImageId != nil {
-			externalImageIds = append(externalImageIds, *match.Face.ExternalImageId)
-		}
+// SearchFaceMatchesWithBucket is SearchFaceWithBucket returning every match with its FaceId and Similarity
+func (r *rekognitionFaceIndexer) SearchFaceMatchesWithBucket(ctx context.Context, s3Bucket string, s3Key string, collectionId string, opts ...CallOption) ([]FaceMatchResult, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return nil, err
+	}
+	o := newCallOptions(opts)
+	resp, err := r.searchFacesByBucket(ctx, s3Bucket, s3Key, collectionId, o)
+	if err != nil {
+		return nil, err
 	}
 
-	// Use lo.Uniq to filter out duplicate ExternalImageIds
-	uniqueExternalImageIds := lo.Uniq(externalImageIds)
-
-	return uniqueExternalImageIds, nil
+	matches := faceMatchResults(resp.FaceMatches, o)
+	return matches, noMatchError(len(matches), o)
 }
 
-func (r *rekognitionFaceIndexer) SearchFacebyFaceId(ctx context.Context, imageSelfieId string, collectionId string) ([]string, error) {
-	// Prepare the input for the SearchFacesByImage API
-	input := &rekognition.SearchFacesInput{
-		CollectionId: aws.String(collectionId),  // The collection where the face is stored
-		FaceId:       aws.String(imageSelfieId), // The FaceId we want to search for
-	}
-	log.Printf("Try to find this generated face id: %s", imageSelfieId)
-	log.Printf("Try to find this collection id: %s", collectionId)
-	// Try to find the collection exists or not
-	inputCheckCollection := &rekognition.DescribeCollectionInput{
-		CollectionId: aws.String(*input.CollectionId),
-	}
-	resp_collection, err := r.client.DescribeCollection(ctx, inputCheckCollection)
+// SearchPhotoMatchesWithBucket is SearchFaceMatchesWithBucket with one match per photo: when
+// several faces of the same ExternalImageId match, the one with the highest Similarity is kept.
+// The matches are ordered by Similarity, highest first, for ranking gallery results.
+func (r *rekognitionFaceIndexer) SearchPhotoMatchesWithBucket(ctx context.Context, s3Bucket string, s3Key string, collectionId string, opts ...CallOption) ([]FaceMatchResult, error) {
+	matches, err := r.SearchFaceMatchesWithBucket(ctx, s3Bucket, s3Key, collectionId, opts...)
 	if err != nil {
-		log.Printf("Error collection : %v", err)
+		return matches, err
 	}
-	json_resp_col, _ := json.Marshal(resp_collection)
-	log.Printf("Try to check this collection : %s", string(json_resp_col))
-	log.Printf("Input payload: %s %s", *input.CollectionId, *input.FaceId)
+	return bestMatchPerPhoto(matches), nil
+}
 
-	// Try to list all the faces in collection
-	inputListFacesCollection := &rekognition.ListFacesInput{
-		CollectionId: aws.String(*input.CollectionId),
-	}
-	resp_list_faces, err := r.client.ListFaces(ctx, inputListFacesCollection)
+func (r *rekognitionFaceIndexer) searchFacesByBucket(ctx context.Context, s3Bucket string, s3Key string, collectionId string, o callOptions) (*rekognition.SearchFacesByImageOutput, error) {
+	image, err := r.s3Image(ctx, s3Bucket, s3Key)
 	if err != nil {
-		log.Printf("Error List Faces : %v", err)
+		return nil, fmt.Errorf("search face failed: %w", err)
 	}
-	json_resp_list_faces, _ := json.Marshal(resp_list_faces)
-	log.Printf("Try to list all faces collection : %s", string(json_resp_list_faces))
 
-	log.Printf("Input payload: %s %s", *input.CollectionId, *input.FaceId)
-	// Call the SearchFacesByImage API
-	resp, err := r.client.SearchFaces(ctx, input)
+	return r.searchFacesByImage(ctx, image, collectionId, 0, o)
+}
+
+// SearchFacebyFaceId searches the stored face against the collection and returns the ExternalImageIds
+// of the matches. A face indexed moments ago may not be searchable yet, so it is retried for up to
+// WithFaceSearchableMaxWait; a FaceId the collection doesn't store fails at once with ErrFaceNotFound.
+func (r *rekognitionFaceIndexer) SearchFacebyFaceId(ctx context.Context, imageSelfieId string, collectionId string, opts ...CallOption) ([]string, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return nil, err
+	}
+	o := newCallOptions(opts)
+	logger := r.logger(ctx)
+	logger.Debug("Search face by id", "collectionId", collectionId, "faceId", imageSelfieId)
+
+	// Call the SearchFaces API, backing off while a just-indexed face isn't searchable yet
+	resp, err := r.waitForFaceSearchable(ctx, collectionId, imageSelfieId, r.faceSearchableMaxWait(), o)
 	if err != nil {
-		log.Printf("error line: %v", err)
+		logger.Error("error line", "error", err)
 		// Check if the error is an InvalidParameterException (no faces in the image)
 		var invalidParamErr *types.InvalidParameterException
 		if errors.As(err, &invalidParamErr) {
 			// Handle the case where no faces were detected in the image
-			log.Printf("Search Face Error: Invalid Parameter")
-			return nil, fmt.Errorf("found this error when search face by id: %v", err)
-		}
-		return nil, fmt.Errorf("failed to search face by id, [Invalid, please try again]: %v", err)
-	}
-
-	// Use a map to ensure uniqueness of ExternalImageId
-	var externalImageIds []string
-	for _, match := range resp.FaceMatches {
-		if match.Face.ExternalImageId != nil {
-			externalImageIds = append(externalImageIds, *match.Face.ExternalImageId)
+			logger.Error("Search Face Error: Invalid Parameter")
+			return nil, fmt.Errorf("found this error when search face by id: %w", err)
 		}
+		return nil, fmt.Errorf("failed to search face by id, [Invalid, please try again]: %w", err)
 	}
 
-	// Use lo.Uniq to filter out duplicate ExternalImageIds
-	uniqueExternalImageIds := lo.Uniq(externalImageIds)
-
-	return uniqueExternalImageIds, nil
+	matches := matchedExternalImageIds(resp.FaceMatches, o)
+	return matches, noMatchError(len(matches), o)
 }
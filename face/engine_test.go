@@ -33,12 +33,13 @@ func loadAwsRekognition() (*rekognition.Client, error) {
 		return nil, err
 	}
 
-	// AWS SDK v2
-	awsV2Credentials := awsv2_credentials.NewStaticCredentialsProvider(envStruct.AwsAccessKeyID, envStruct.AwsSecretAccessKey, "")
-	awsV2Cfg, err := awsv2_config.LoadDefaultConfig(context.Background(),
-		awsv2_config.WithRegion(envStruct.AwsRegion),
-		awsv2_config.WithCredentialsProvider(awsV2Credentials),
-	)
+	// AWS SDK v2, falling back to the default credential chain (SSO, roles) without static keys
+	loadOptions := []func(*awsv2_config.LoadOptions) error{awsv2_config.WithRegion(envStruct.AwsRegion)}
+	if envStruct.AwsAccessKeyID != "" && envStruct.AwsSecretAccessKey != "" {
+		awsV2Credentials := awsv2_credentials.NewStaticCredentialsProvider(envStruct.AwsAccessKeyID, envStruct.AwsSecretAccessKey, "")
+		loadOptions = append(loadOptions, awsv2_config.WithCredentialsProvider(awsV2Credentials))
+	}
+	awsV2Cfg, err := awsv2_config.LoadDefaultConfig(context.Background(), loadOptions...)
 	if err != nil {
 		log.Fatalf("unable to load SDK config, %v", err)
 	}
@@ -1,8 +1,58 @@
 package face
 
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+)
+
 type Env struct {
 	AwsRegion          string `env:"AWS_REGION" json:"AWS_REGION"`
 	AwsAccessKeyID     string `env:"AWS_ACCESS_KEY_ID" json:"AWS_ACCESS_KEY_ID"`
 	AwsSecretAccessKey string `env:"AWS_SECRET_ACCESS_KEY" json:"AWS_SECRET_ACCESS_KEY"`
 	AwsBucketName      string `env:"AWS_BUCKET_NAME" json:"AWS_BUCKET_NAME"`
 }
+
+// LoadEnv reads the Env fields from the process environment
+func LoadEnv() Env {
+	return Env{
+		AwsRegion:          os.Getenv("AWS_REGION"),
+		AwsAccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		AwsSecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		AwsBucketName:      os.Getenv("AWS_BUCKET_NAME"),
+	}
+}
+
+// NewFromEnv builds its own Rekognition client from the environment (see LoadEnv)
+// and returns a Face backed by it. Use the Option helpers to tune the client.
+// Static keys are used when AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are both set;
+// otherwise credentials come from the SDK's default chain (shared config and SSO
+// profiles, web identity, ECS and EC2 roles), so no keys need to be hardcoded.
+func NewFromEnv(ctx context.Context, opts ...Option) (Face, error) {
+	o := newOptions(opts)
+	env := LoadEnv()
+
+	var loadOptions []func(*config.LoadOptions) error
+	if env.AwsRegion != "" {
+		loadOptions = append(loadOptions, config.WithRegion(env.AwsRegion))
+	}
+	if env.AwsAccessKeyID != "" && env.AwsSecretAccessKey != "" {
+		loadOptions = append(loadOptions, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(env.AwsAccessKeyID, env.AwsSecretAccessKey, "")))
+	}
+	if o.retryMaxAttempts > 0 {
+		loadOptions = append(loadOptions, config.WithRetryMaxAttempts(o.retryMaxAttempts))
+	}
+	if o.retryMode != "" {
+		loadOptions = append(loadOptions, config.WithRetryMode(o.retryMode))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, loadOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load SDK config: %v", err)
+	}
+	return NewRekognitionFaceIndexer(rekognition.NewFromConfig(cfg), opts...), nil
+}
@@ -0,0 +1,67 @@
+package face
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+)
+
+func TestNewFromEnvRetryOptions(t *testing.T) {
+	t.Setenv("AWS_REGION", "ap-southeast-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+
+	faceIndexer, err := NewFromEnv(context.TODO(), WithRetryMaxAttempts(7), WithRetryMode(aws.RetryModeAdaptive))
+	if err != nil {
+		t.Fatalf("error creating indexer: %v", err)
+	}
+
+	clientOptions := faceIndexer.(*rekognitionFaceIndexer).client.(*rekognition.Client).Options()
+	if clientOptions.RetryMaxAttempts != 7 {
+		t.Fatalf("RetryMaxAttempts = %d, want 7", clientOptions.RetryMaxAttempts)
+	}
+	if clientOptions.RetryMode != aws.RetryModeAdaptive {
+		t.Fatalf("RetryMode = %s, want %s", clientOptions.RetryMode, aws.RetryModeAdaptive)
+	}
+	if clientOptions.Region != "ap-southeast-1" {
+		t.Fatalf("Region = %s, want ap-southeast-1", clientOptions.Region)
+	}
+}
+
+func TestNewFromEnvCredentials(t *testing.T) {
+	tests := []struct {
+		name       string
+		accessKey  string
+		secretKey  string
+		wantStatic bool
+	}{
+		{name: "static keys", accessKey: "test", secretKey: "test", wantStatic: true},
+		{name: "default chain", accessKey: "", secretKey: "", wantStatic: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AWS_REGION", "ap-southeast-1")
+			t.Setenv("AWS_ACCESS_KEY_ID", tt.accessKey)
+			t.Setenv("AWS_SECRET_ACCESS_KEY", tt.secretKey)
+			t.Setenv("AWS_CONFIG_FILE", "/nonexistent")
+			t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent")
+
+			faceIndexer, err := NewFromEnv(context.TODO())
+			if err != nil {
+				t.Fatalf("error creating indexer: %v", err)
+			}
+
+			provider := faceIndexer.(*rekognitionFaceIndexer).client.(*rekognition.Client).Options().Credentials
+			_, static := provider.(credentials.StaticCredentialsProvider)
+			if cache, ok := provider.(*aws.CredentialsCache); ok {
+				static = cache.IsCredentialsProvider(credentials.StaticCredentialsProvider{})
+			}
+			if static != tt.wantStatic {
+				t.Fatalf("static credentials = %v, want %v", static, tt.wantStatic)
+			}
+		})
+	}
+}
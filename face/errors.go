@@ -0,0 +1,90 @@
+package face
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+	"github.com/samber/lo"
+)
+
+var (
+	// ErrUnsupportedImageFormat is returned when image bytes are not a JPEG or PNG.
+	ErrUnsupportedImageFormat = errors.New("unsupported image format, Rekognition only accepts jpeg and png")
+	// ErrInvalidImageFormat is returned, wrapping the AWS error, when Rekognition itself rejects the
+	// image with InvalidImageFormatException, e.g. a truncated upload that passed the format check.
+	ErrInvalidImageFormat = errors.New("invalid image format, Rekognition couldn't read the image")
+	// ErrEmptyImage is returned when no image bytes are given.
+	ErrEmptyImage = errors.New("empty image")
+	// ErrInvalidCollectionId is returned when a collection id is empty or not one Rekognition accepts.
+	ErrInvalidCollectionId = errors.New("invalid collection id")
+	// ErrInvalidImageDimensions is returned when the image is smaller than Rekognition accepts.
+	ErrInvalidImageDimensions = errors.New("invalid image dimensions")
+	// ErrInvalidBoundingBox is returned when a bounding box doesn't overlap the image.
+	ErrInvalidBoundingBox = errors.New("invalid bounding box")
+	// ErrNoFaceIndexed is returned when IndexFaces enrolled no face from the image.
+	ErrNoFaceIndexed = errors.New("no face indexed")
+	// ErrNoFaceDetected is returned when DetectFaces finds no face in the image.
+	ErrNoFaceDetected = errors.New("no face detected in the image")
+	// ErrNoFaceInSource is returned when the source (reference) image of a comparison has no face.
+	// It matches ErrNoFaceDetected.
+	ErrNoFaceInSource = fmt.Errorf("%w: source image", ErrNoFaceDetected)
+	// ErrNoFaceInTarget is returned when the target (new) image of a comparison has no face.
+	// It matches ErrNoFaceDetected.
+	ErrNoFaceInTarget = fmt.Errorf("%w: target image", ErrNoFaceDetected)
+	// ErrLivenessSessionNotSucceeded is returned when a Face Liveness session hasn't (yet) succeeded.
+	ErrLivenessSessionNotSucceeded = errors.New("liveness session has not succeeded")
+	// ErrLowQuality is returned when the faces of an image are too blurry or too dark to enroll.
+	ErrLowQuality = errors.New("face quality too low")
+	// ErrMultipleFaces is returned when a single-person image has more than one face.
+	ErrMultipleFaces = errors.New("more than one face in the image")
+	// ErrDownloadFailed is returned when an image can't be downloaded, e.g. on a non-200 response.
+	ErrDownloadFailed = errors.New("failed to download image")
+	// ErrImageTooLarge is returned when an image is larger than the 5MB Rekognition accepts as bytes,
+	// or than the 15MB it accepts from S3.
+	ErrImageTooLarge = errors.New("image too large")
+	// ErrFaceNotFound is returned when a FaceId isn't stored in the collection.
+	ErrFaceNotFound = errors.New("face not found in the collection")
+	// ErrCollectionNotFound is returned when a collection doesn't exist in the client's region.
+	ErrCollectionNotFound = errors.New("collection not found")
+	// ErrCollectionAccessDenied is returned when the credentials aren't allowed to use a collection.
+	ErrCollectionAccessDenied = errors.New("collection access denied")
+	// ErrNoMatch is returned by searches that found no match when the call opts in with WithNoMatchError.
+	ErrNoMatch = errors.New("no matching face found")
+	// ErrAlreadyIndexed is returned when indexing is skipped because the WithSeenContent callback
+	// reports the image content as already indexed.
+	ErrAlreadyIndexed = errors.New("image content already indexed")
+	// ErrFaceModelVersionMismatch is returned when a collection doesn't use the face model version pinned with WithFaceModelVersion.
+	ErrFaceModelVersionMismatch = errors.New("face model version mismatch")
+	// ErrInvalidExternalImageId is returned when fields can't be encoded into, or decoded from, an ExternalImageId.
+	ErrInvalidExternalImageId = errors.New("invalid external image id")
+)
+
+// UnindexedFacesError reports why IndexFaces enrolled nothing, e.g. because
+// the quality filter rejected every detected face. It matches ErrNoFaceIndexed.
+type UnindexedFacesError struct {
+	// Reasons are the unique reasons Rekognition gave for the faces it detected
+	// but didn't index. It is empty when no face was detected at all.
+	Reasons []types.Reason
+	// UnindexedFaces are the faces detected but not indexed, each with its own reasons
+	UnindexedFaces []types.UnindexedFace
+}
+
+func newUnindexedFacesError(unindexedFaces []types.UnindexedFace) *UnindexedFacesError {
+	var reasons []types.Reason
+	for _, unindexedFace := range unindexedFaces {
+		reasons = append(reasons, unindexedFace.Reasons...)
+	}
+	return &UnindexedFacesError{Reasons: lo.Uniq(reasons), UnindexedFaces: unindexedFaces}
+}
+
+func (e *UnindexedFacesError) Error() string {
+	if len(e.Reasons) == 0 {
+		return fmt.Sprintf("%v: no face detected in the image", ErrNoFaceIndexed)
+	}
+	return fmt.Sprintf("%v: faces rejected for %v", ErrNoFaceIndexed, e.Reasons)
+}
+
+func (e *UnindexedFacesError) Unwrap() error {
+	return ErrNoFaceIndexed
+}
@@ -0,0 +1,98 @@
+package face
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+// ExportedFace is a stored face as written by ExportCollection, one JSON object per line.
+type ExportedFace struct {
+	FaceId          string            `json:"faceId"`
+	ExternalImageId string            `json:"externalImageId"`
+	Confidence      float32           `json:"confidence"`
+	BoundingBox     types.BoundingBox `json:"boundingBox"`
+	ImageId         string            `json:"imageId"`
+}
+
+// ExportCollection writes every face of the collection to w as newline-delimited JSON, e.g. to
+// back it up or migrate it. Faces are streamed page by page as ListFaces returns them.
+// Rekognition doesn't expose face vectors, so the export records where faces came from rather
+// than the faces themselves.
+func (r *rekognitionFaceIndexer) ExportCollection(ctx context.Context, collectionId string, w io.Writer) error {
+	if err := validateCollectionId(collectionId); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	err := r.forEachFace(ctx, collectionId, callOptions{}, func(face types.Face) error {
+		return enc.Encode(exportedFace(face))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export collection %s: %w", collectionId, err)
+	}
+	return nil
+}
+
+// ImportCollection re-indexes into the collection the faces ExportCollection wrote to r, keeping
+// their ExternalImageIds. Rekognition can't restore face vectors, so each photo is fetched with
+// fetchImage and indexed again; a photo holding several exported faces is indexed once, and the
+// faces get new FaceIds. A photo that fails to fetch or index doesn't stop the import: every such
+// failure is joined into the returned error. A malformed export aborts the import.
+func (r *rekognitionFaceIndexer) ImportCollection(ctx context.Context, collectionId string, rd io.Reader, fetchImage ImageFetcher, opts ...CallOption) error {
+	if err := validateCollectionId(collectionId); err != nil {
+		return err
+	}
+	o := newCallOptions(opts)
+	imported := make(map[string]bool)
+	var errs []error
+	dec := json.NewDecoder(rd)
+	for {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("failed to import collection %s: %w", collectionId, err)
+		}
+		var face ExportedFace
+		if err := dec.Decode(&face); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to import collection %s: %w", collectionId, err)
+		}
+		if imported[face.ExternalImageId] {
+			continue
+		}
+		imported[face.ExternalImageId] = true
+
+		if err := r.importFace(ctx, face, collectionId, fetchImage, o); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *rekognitionFaceIndexer) importFace(ctx context.Context, face ExportedFace, collectionId string, fetchImage ImageFetcher, o callOptions) error {
+	image, err := fetchImage(face.ExternalImageId)
+	if err != nil {
+		return fmt.Errorf("failed to fetch image %s: %w", face.ExternalImageId, err)
+	}
+	if _, err := r.indexFaceBytes(ctx, image, face.ExternalImageId, collectionId, o); err != nil {
+		return fmt.Errorf("failed to import face %s: %w", face.FaceId, err)
+	}
+	return nil
+}
+
+func exportedFace(face types.Face) ExportedFace {
+	exported := ExportedFace{
+		FaceId:          aws.ToString(face.FaceId),
+		ExternalImageId: aws.ToString(face.ExternalImageId),
+		Confidence:      aws.ToFloat32(face.Confidence),
+		ImageId:         aws.ToString(face.ImageId),
+	}
+	if face.BoundingBox != nil {
+		exported.BoundingBox = *face.BoundingBox
+	}
+	return exported
+}
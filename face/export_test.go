@@ -0,0 +1,98 @@
+package face
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func TestExportCollection(t *testing.T) {
+	var faces []types.Face
+	for i := 0; i < 5; i++ {
+		bbox := boundingBox(0.1, 0.2, 0.3, 0.4)
+		faces = append(faces, types.Face{
+			FaceId:          aws.String(fmt.Sprintf("face-%d", i)),
+			ExternalImageId: aws.String(fmt.Sprintf("photo_%d", i)),
+			ImageId:         aws.String(fmt.Sprintf("image-%d", i)),
+			Confidence:      aws.Float32(99.5),
+			BoundingBox:     &bbox,
+		})
+	}
+	fake := &fakeRekognition{listFaces: pagedListFaces(faces, 2)}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	var buf bytes.Buffer
+	if err := faceIndexer.ExportCollection(context.TODO(), "event_1", &buf); err != nil {
+		t.Fatalf("error exporting collection: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(faces) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(faces))
+	}
+	for i, line := range lines {
+		var got ExportedFace
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("error decoding line %d: %v", i, err)
+		}
+		if got.FaceId != fmt.Sprintf("face-%d", i) || got.ExternalImageId != fmt.Sprintf("photo_%d", i) ||
+			got.ImageId != fmt.Sprintf("image-%d", i) || got.Confidence != 99.5 || aws.ToFloat32(got.BoundingBox.Width) != 0.3 {
+			t.Fatalf("line %d = %+v, want face-%d", i, got, i)
+		}
+	}
+	if calls := fake.count("ListFaces"); calls != 3 {
+		t.Fatalf("ListFaces called %d times, want 3", calls)
+	}
+}
+
+func TestImportCollection(t *testing.T) {
+	export := strings.Join([]string{
+		`{"faceId":"face-1","externalImageId":"photo_1"}`,
+		`{"faceId":"face-2","externalImageId":"photo_1"}`,
+		`{"faceId":"face-3","externalImageId":"photo_missing"}`,
+		`{"faceId":"face-4","externalImageId":"photo_2"}`,
+	}, "\n")
+	var indexed []string
+	fake := &fakeRekognition{
+		indexFaces: func(input *rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			indexed = append(indexed, aws.ToString(input.ExternalImageId))
+			return &rekognition.IndexFacesOutput{FaceRecords: []types.FaceRecord{{Face: &types.Face{FaceId: aws.String("new-face")}}}}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+	errFetch := errors.New("no such photo")
+	fetchImage := func(externalImageId string) ([]byte, error) {
+		if externalImageId == "photo_missing" {
+			return nil, errFetch
+		}
+		return testJPEG(t, 100, 100), nil
+	}
+
+	err := faceIndexer.ImportCollection(context.TODO(), "event_2", strings.NewReader(export), fetchImage)
+	if !errors.Is(err, errFetch) {
+		t.Fatalf("got error %v, want %v", err, errFetch)
+	}
+	if want := []string{"photo_1", "photo_2"}; !reflect.DeepEqual(indexed, want) {
+		t.Fatalf("indexed %v, want %v", indexed, want)
+	}
+}
+
+func TestImportCollectionMalformed(t *testing.T) {
+	faceIndexer := &rekognitionFaceIndexer{client: &fakeRekognition{}}
+	fetchImage := func(string) ([]byte, error) {
+		return testJPEG(t, 100, 100), nil
+	}
+
+	err := faceIndexer.ImportCollection(context.TODO(), "event_2", strings.NewReader(`{"faceId":`), fetchImage)
+	if err == nil {
+		t.Fatalf("got no error importing a malformed export")
+	}
+}
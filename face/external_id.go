@@ -0,0 +1,136 @@
+package face
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxExternalImageIdLength is the longest ExternalImageId Rekognition accepts
+const maxExternalImageIdLength = 255
+
+// EncodeExternalImageId packs fields, e.g. a capture session id and a photo id, into a
+// single ExternalImageId that DecodeExternalImageId turns back into the same map.
+//
+// Fields are sorted by key and written as "key:value" pairs separated by ".". Every
+// character of keys and values other than [a-zA-Z0-9-] is escaped as "_" followed by
+// its two hex digits per byte (so "." becomes "_2E" and "_" becomes "_5F"), which keeps
+// the result within the characters Rekognition allows: [a-zA-Z0-9_.\-:].
+func EncodeExternalImageId(fields map[string]string) (string, error) {
+	if len(fields) == 0 {
+		return "", fmt.Errorf("%w: no fields", ErrInvalidExternalImageId)
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		if key == "" {
+			return "", fmt.Errorf("%w: empty field name", ErrInvalidExternalImageId)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, escapeExternalImageIdField(key)+":"+escapeExternalImageIdField(fields[key]))
+	}
+	externalImageId := strings.Join(pairs, ".")
+	if len(externalImageId) > maxExternalImageIdLength {
+		return "", fmt.Errorf("%w: encoded to %d characters, more than %d", ErrInvalidExternalImageId, len(externalImageId), maxExternalImageIdLength)
+	}
+	return externalImageId, nil
+}
+
+// DecodeExternalImageId unpacks the fields of an ExternalImageId made by EncodeExternalImageId.
+func DecodeExternalImageId(externalImageId string) (map[string]string, error) {
+	if externalImageId == "" {
+		return nil, fmt.Errorf("%w: empty", ErrInvalidExternalImageId)
+	}
+
+	fields := make(map[string]string)
+	for _, pair := range strings.Split(externalImageId, ".") {
+		escapedKey, escapedValue, ok := strings.Cut(pair, ":")
+		if !ok || escapedKey == "" {
+			return nil, fmt.Errorf("%w: %q is not a key:value pair", ErrInvalidExternalImageId, pair)
+		}
+		key, err := unescapeExternalImageIdField(escapedKey)
+		if err != nil {
+			return nil, err
+		}
+		value, err := unescapeExternalImageIdField(escapedValue)
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = value
+	}
+	return fields, nil
+}
+
+// NormalizeExternalImageId canonicalizes an ExternalImageId so the same logical id is always
+// written and compared in one form. Surrounding whitespace and "." separators are trimmed, and
+// every character Rekognition doesn't allow is escaped like EncodeExternalImageId does, so
+// "photo 1." becomes "photo_201". Case is kept: Rekognition ids are case-sensitive, and so are
+// the values EncodeExternalImageId packs. Ids made by EncodeExternalImageId are left as is.
+// Escaping makes the id longer; indexing fails with ErrInvalidExternalImageId when the
+// result is over the 255 characters Rekognition accepts.
+func NormalizeExternalImageId(externalImageId string) string {
+	externalImageId = strings.Trim(strings.TrimSpace(externalImageId), ".")
+	var b strings.Builder
+	for i := 0; i < len(externalImageId); i++ {
+		c := externalImageId[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.' || c == ':' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "_%02X", c)
+	}
+	return b.String()
+}
+
+// validateExternalImageId rejects a normalized ExternalImageId longer than Rekognition accepts.
+// Escaping grows ids, so an id that fit before normalizing may not fit after.
+func validateExternalImageId(externalImageId string) error {
+	if len(externalImageId) > maxExternalImageIdLength {
+		return fmt.Errorf("%w: %d characters once normalized, more than %d", ErrInvalidExternalImageId, len(externalImageId), maxExternalImageIdLength)
+	}
+	return nil
+}
+
+// Fields decodes the ExternalImageId of the match, see DecodeExternalImageId
+func (m FaceMatchResult) Fields() (map[string]string, error) {
+	return DecodeExternalImageId(m.ExternalImageId)
+}
+
+func escapeExternalImageIdField(field string) string {
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		c := field[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "_%02X", c)
+	}
+	return b.String()
+}
+
+func unescapeExternalImageIdField(field string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] != '_' {
+			b.WriteByte(field[i])
+			continue
+		}
+		if i+2 >= len(field) {
+			return "", fmt.Errorf("%w: truncated escape in %q", ErrInvalidExternalImageId, field)
+		}
+		c, err := strconv.ParseUint(field[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("%w: invalid escape in %q", ErrInvalidExternalImageId, field)
+		}
+		b.WriteByte(byte(c))
+		i += 2
+	}
+	return b.String(), nil
+}
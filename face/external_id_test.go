@@ -0,0 +1,116 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func TestExternalImageIdRoundTrip(t *testing.T) {
+	legal := regexp.MustCompile(`^[a-zA-Z0-9_.\-:]+$`)
+	tests := []map[string]string{
+		{"session": "s-42", "photo": "IMG_0001.jpg"},
+		{"photo": "a:b.c_d e/f", "empty": ""},
+		{"name": "Zoë"},
+	}
+	for _, fields := range tests {
+		externalImageId, err := EncodeExternalImageId(fields)
+		if err != nil {
+			t.Fatalf("error encoding %v: %v", fields, err)
+		}
+		if !legal.MatchString(externalImageId) {
+			t.Fatalf("%q has characters Rekognition doesn't allow", externalImageId)
+		}
+		decoded, err := (FaceMatchResult{ExternalImageId: externalImageId}).Fields()
+		if err != nil {
+			t.Fatalf("error decoding %q: %v", externalImageId, err)
+		}
+		if !reflect.DeepEqual(decoded, fields) {
+			t.Fatalf("%q decoded to %v, want %v", externalImageId, decoded, fields)
+		}
+	}
+
+	externalImageId, _ := EncodeExternalImageId(map[string]string{"session": "s-42", "photo": "IMG_0001.jpg"})
+	if want := "photo:IMG_5F0001_2Ejpg.session:s-42"; externalImageId != want {
+		t.Fatalf("got %q, want %q", externalImageId, want)
+	}
+}
+
+func TestExternalImageIdInvalid(t *testing.T) {
+	if _, err := EncodeExternalImageId(nil); !errors.Is(err, ErrInvalidExternalImageId) {
+		t.Fatalf("got error %v encoding no fields, want %v", err, ErrInvalidExternalImageId)
+	}
+	if _, err := EncodeExternalImageId(map[string]string{"photo": strings.Repeat("x", 300)}); !errors.Is(err, ErrInvalidExternalImageId) {
+		t.Fatalf("got error %v encoding a long value, want %v", err, ErrInvalidExternalImageId)
+	}
+	for _, externalImageId := range []string{"", "photo_1", "photo:a_2", "photo:a_ZZ"} {
+		if _, err := DecodeExternalImageId(externalImageId); !errors.Is(err, ErrInvalidExternalImageId) {
+			t.Fatalf("got error %v decoding %q, want %v", err, externalImageId, ErrInvalidExternalImageId)
+		}
+	}
+}
+
+func TestNormalizeExternalImageId(t *testing.T) {
+	encoded, _ := EncodeExternalImageId(map[string]string{"session": "s-42", "photo": "IMG_0001.jpg"})
+	tests := []struct {
+		externalImageId string
+		want            string
+	}{
+		{externalImageId: "photo_1", want: "photo_1"},
+		{externalImageId: " photo_1.\n", want: "photo_1"},
+		{externalImageId: "..photo_1..", want: "photo_1"},
+		{externalImageId: "photo 1", want: "photo_201"},
+		{externalImageId: "Photo_1", want: "Photo_1"},
+		{externalImageId: encoded, want: encoded},
+	}
+	for _, tt := range tests {
+		if got := NormalizeExternalImageId(tt.externalImageId); got != tt.want {
+			t.Fatalf("NormalizeExternalImageId(%q) = %q, want %q", tt.externalImageId, got, tt.want)
+		}
+	}
+}
+
+func TestMatchedExternalImageIdsNormalized(t *testing.T) {
+	matches := []types.FaceMatch{
+		faceMatch("face-1", "photo_1", 99),
+		faceMatch("face-2", "photo_1.", 98),
+		faceMatch("face-3", " photo_2", 97),
+	}
+
+	got := matchedExternalImageIds(matches, callOptions{})
+	if want := []string{"photo_1", "photo_2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestIndexFaceRejectsExternalImageIdTooLongOnceNormalized(t *testing.T) {
+	fake := &fakeRekognition{}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	// 201 characters, whose 100 spaces are each escaped to 3
+	err := faceIndexer.IndexFace(context.TODO(), testJPEG(t, 100, 100), strings.Repeat("x ", 100)+"x", "event_1")
+	if !errors.Is(err, ErrInvalidExternalImageId) {
+		t.Fatalf("got error %v, want %v", err, ErrInvalidExternalImageId)
+	}
+	if got := fake.count("IndexFaces"); got != 0 {
+		t.Fatalf("got %d IndexFaces calls, want none", got)
+	}
+}
+
+func TestIndexFacesBatchNormalizesExternalImageId(t *testing.T) {
+	faceIndexer := &rekognitionFaceIndexer{client: &fakeRekognition{}}
+
+	items := []BatchItem{{Image: testJPEG(t, 100, 100), ExternalImageId: "photo 1"}}
+	results, err := faceIndexer.IndexFacesBatch(context.TODO(), "event_1", items, BatchOptions{})
+	if err != nil {
+		t.Fatalf("error indexing batch: %v", err)
+	}
+	if results[0].ExternalImageId != "photo_201" {
+		t.Fatalf("got ExternalImageId %q, want photo_201", results[0].ExternalImageId)
+	}
+}
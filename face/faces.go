@@ -0,0 +1,121 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+// FaceExists reports whether faceId is still stored in the collection. A missing
+// collection is reported as false rather than as an error.
+func (r *rekognitionFaceIndexer) FaceExists(ctx context.Context, collectionId string, faceId string) (bool, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return false, err
+	}
+	return r.faceExists(ctx, collectionId, faceId, callOptions{})
+}
+
+func (r *rekognitionFaceIndexer) faceExists(ctx context.Context, collectionId string, faceId string, o callOptions) (bool, error) {
+	resp, err := invoke(ctx, r, "ListFaces", r.client.ListFaces, &rekognition.ListFacesInput{
+		CollectionId: aws.String(collectionId),
+		FaceIds:      []string{faceId},
+	}, o.apiOptions...)
+	if err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check face exists: %w", err)
+	}
+
+	for _, face := range resp.Faces {
+		if aws.ToString(face.FaceId) == faceId {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// listFacesPageSize is the largest page ListFaces accepts
+const listFacesPageSize = 4096
+
+// ListFacesPage returns a single page of up to pageSize faces of the collection and the token
+// of the next one, empty on the last page, for cursor-based navigation. Pass an empty
+// nextToken for the first page. pageSize is capped at 4096, and 0 uses Rekognition's default.
+func (r *rekognitionFaceIndexer) ListFacesPage(ctx context.Context, collectionId string, nextToken string, pageSize int32) ([]types.Face, string, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return nil, "", err
+	}
+	input := &rekognition.ListFacesInput{
+		CollectionId: aws.String(collectionId),
+	}
+	if nextToken != "" {
+		input.NextToken = aws.String(nextToken)
+	}
+	if pageSize > 0 {
+		input.MaxResults = aws.Int32(min(pageSize, listFacesPageSize))
+	}
+	resp, err := invoke(ctx, r, "ListFaces", r.client.ListFaces, input)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list faces page: %w", err)
+	}
+	return resp.Faces, aws.ToString(resp.NextToken), nil
+}
+
+// ListFacesByExternalImageId returns every face indexed from the photo with the given ExternalImageId,
+// compared in normalized form (see NormalizeExternalImageId).
+// ListFaces can't filter by ExternalImageId server-side, so the whole collection is paginated
+// and filtered client-side.
+func (r *rekognitionFaceIndexer) ListFacesByExternalImageId(ctx context.Context, collectionId string, externalImageId string) ([]types.Face, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return nil, err
+	}
+	return r.listFacesByExternalImageId(ctx, collectionId, externalImageId, callOptions{})
+}
+
+// listFacesByExternalImageId is ListFacesByExternalImageId with the call's options
+func (r *rekognitionFaceIndexer) listFacesByExternalImageId(ctx context.Context, collectionId string, externalImageId string, o callOptions) ([]types.Face, error) {
+	externalImageId = NormalizeExternalImageId(externalImageId)
+	var faces []types.Face
+	err := r.forEachFace(ctx, collectionId, o, func(face types.Face) error {
+		if NormalizeExternalImageId(aws.ToString(face.ExternalImageId)) == externalImageId {
+			faces = append(faces, face)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list faces by external image id: %w", err)
+	}
+	return faces, nil
+}
+
+// forEachFace paginates ListFaces over the whole collection, calling fn for every face.
+// It stops at the first error returned by fn or by the context.
+func (r *rekognitionFaceIndexer) forEachFace(ctx context.Context, collectionId string, o callOptions, fn func(face types.Face) error) error {
+	input := &rekognition.ListFacesInput{
+		CollectionId: aws.String(collectionId),
+		MaxResults:   aws.Int32(listFacesPageSize),
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		resp, err := invoke(ctx, r, "ListFaces", r.client.ListFaces, input, o.apiOptions...)
+		if err != nil {
+			return err
+		}
+		for _, face := range resp.Faces {
+			if err := fn(face); err != nil {
+				return err
+			}
+		}
+		if aws.ToString(resp.NextToken) == "" {
+			return nil
+		}
+		input.NextToken = resp.NextToken
+	}
+}
@@ -0,0 +1,129 @@
+package face
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func TestFaceExists(t *testing.T) {
+	fake := &fakeRekognition{
+		listFaces: func(input *rekognition.ListFacesInput) (*rekognition.ListFacesOutput, error) {
+			if aws.ToString(input.CollectionId) == "missing" {
+				return nil, awsOperationError("ListFaces", "req-1", 400, &types.ResourceNotFoundException{})
+			}
+			var faces []types.Face
+			for _, faceId := range input.FaceIds {
+				if faceId == "face-1" {
+					faces = append(faces, types.Face{FaceId: aws.String(faceId)})
+				}
+			}
+			return &rekognition.ListFacesOutput{Faces: faces}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	tests := []struct {
+		collectionId string
+		faceId       string
+		want         bool
+	}{
+		{collectionId: "event_1", faceId: "face-1", want: true},
+		{collectionId: "event_1", faceId: "face-deleted", want: false},
+		{collectionId: "missing", faceId: "face-1", want: false},
+	}
+	for _, tt := range tests {
+		exists, err := faceIndexer.FaceExists(context.TODO(), tt.collectionId, tt.faceId)
+		if err != nil {
+			t.Fatalf("error checking %s in %s: %v", tt.faceId, tt.collectionId, err)
+		}
+		if exists != tt.want {
+			t.Fatalf("FaceExists(%s, %s) = %v, want %v", tt.collectionId, tt.faceId, exists, tt.want)
+		}
+	}
+}
+
+// pagedListFaces serves faces from ListFaces in pages of pageSize
+func pagedListFaces(faces []types.Face, pageSize int) func(*rekognition.ListFacesInput) (*rekognition.ListFacesOutput, error) {
+	return func(input *rekognition.ListFacesInput) (*rekognition.ListFacesOutput, error) {
+		start := 0
+		if input.NextToken != nil {
+			start, _ = strconv.Atoi(*input.NextToken)
+		}
+		end := min(start+pageSize, len(faces))
+		resp := &rekognition.ListFacesOutput{Faces: faces[start:end]}
+		if end < len(faces) {
+			resp.NextToken = aws.String(strconv.Itoa(end))
+		}
+		return resp, nil
+	}
+}
+
+func TestListFacesByExternalImageId(t *testing.T) {
+	var faces []types.Face
+	for i := 0; i < 10; i++ {
+		faces = append(faces, types.Face{
+			FaceId:          aws.String(fmt.Sprintf("face-%d", i)),
+			ExternalImageId: aws.String(fmt.Sprintf("photo_%d", i%3)),
+		})
+	}
+	fake := &fakeRekognition{listFaces: pagedListFaces(faces, 4)}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	matched, err := faceIndexer.ListFacesByExternalImageId(context.TODO(), "event_1", "photo_1")
+	if err != nil {
+		t.Fatalf("error listing faces: %v", err)
+	}
+	var got []string
+	for _, face := range matched {
+		got = append(got, aws.ToString(face.FaceId))
+	}
+	if want := []string{"face-1", "face-4", "face-7"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if calls := fake.count("ListFaces"); calls != 3 {
+		t.Fatalf("ListFaces called %d times, want 3", calls)
+	}
+}
+
+func TestListFacesPage(t *testing.T) {
+	var faces []types.Face
+	for i := 0; i < 10; i++ {
+		faces = append(faces, types.Face{FaceId: aws.String(fmt.Sprintf("face-%d", i))})
+	}
+	var maxResults []int32
+	pages := pagedListFaces(faces, 4)
+	fake := &fakeRekognition{
+		listFaces: func(input *rekognition.ListFacesInput) (*rekognition.ListFacesOutput, error) {
+			maxResults = append(maxResults, aws.ToInt32(input.MaxResults))
+			return pages(input)
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	var pageSizes []int
+	next := ""
+	for {
+		page, token, err := faceIndexer.ListFacesPage(context.TODO(), "event_1", next, 10000)
+		if err != nil {
+			t.Fatalf("error listing faces page: %v", err)
+		}
+		pageSizes = append(pageSizes, len(page))
+		if token == "" {
+			break
+		}
+		next = token
+	}
+	if want := []int{4, 4, 2}; !reflect.DeepEqual(pageSizes, want) {
+		t.Fatalf("got pages of %v faces, want %v", pageSizes, want)
+	}
+	if want := []int32{4096, 4096, 4096}; !reflect.DeepEqual(maxResults, want) {
+		t.Fatalf("got MaxResults %v, want %v", maxResults, want)
+	}
+}
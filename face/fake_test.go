@@ -0,0 +1,176 @@
+package face
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+// fakeRekognition is an in-memory rekognitionAPI used by the unit tests.
+// Each operation can be overridden with a hook; otherwise it returns a
+// minimal successful response. Call counts are recorded per operation, as
+// are the calls made with per-call SDK options.
+type fakeRekognition struct {
+	mu            sync.Mutex
+	calls         map[string]int
+	callsWithOpts map[string]int
+
+	associateFaces     func(*rekognition.AssociateFacesInput) (*rekognition.AssociateFacesOutput, error)
+	compareFaces       func(*rekognition.CompareFacesInput) (*rekognition.CompareFacesOutput, error)
+	createCollection   func(*rekognition.CreateCollectionInput) (*rekognition.CreateCollectionOutput, error)
+	createLiveness     func(*rekognition.CreateFaceLivenessSessionInput) (*rekognition.CreateFaceLivenessSessionOutput, error)
+	createUser         func(*rekognition.CreateUserInput) (*rekognition.CreateUserOutput, error)
+	deleteFaces        func(*rekognition.DeleteFacesInput) (*rekognition.DeleteFacesOutput, error)
+	describeCollection func(*rekognition.DescribeCollectionInput) (*rekognition.DescribeCollectionOutput, error)
+	detectFaces        func(*rekognition.DetectFacesInput) (*rekognition.DetectFacesOutput, error)
+	getLivenessResults func(*rekognition.GetFaceLivenessSessionResultsInput) (*rekognition.GetFaceLivenessSessionResultsOutput, error)
+	indexFaces         func(*rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error)
+	listFaces          func(*rekognition.ListFacesInput) (*rekognition.ListFacesOutput, error)
+	searchFaces        func(*rekognition.SearchFacesInput) (*rekognition.SearchFacesOutput, error)
+	searchFacesByImage func(*rekognition.SearchFacesByImageInput) (*rekognition.SearchFacesByImageOutput, error)
+}
+
+func (f *fakeRekognition) record(op string, optFns []func(*rekognition.Options)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.calls == nil {
+		f.calls = make(map[string]int)
+		f.callsWithOpts = make(map[string]int)
+	}
+	f.calls[op]++
+	if len(optFns) > 0 {
+		f.callsWithOpts[op]++
+	}
+}
+
+func (f *fakeRekognition) count(op string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[op]
+}
+
+func (f *fakeRekognition) countWithOpts(op string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.callsWithOpts[op]
+}
+
+func (f *fakeRekognition) AssociateFaces(ctx context.Context, params *rekognition.AssociateFacesInput, optFns ...func(*rekognition.Options)) (*rekognition.AssociateFacesOutput, error) {
+	f.record("AssociateFaces", optFns)
+	if f.associateFaces != nil {
+		return f.associateFaces(params)
+	}
+	return &rekognition.AssociateFacesOutput{}, nil
+}
+
+func (f *fakeRekognition) CompareFaces(ctx context.Context, params *rekognition.CompareFacesInput, optFns ...func(*rekognition.Options)) (*rekognition.CompareFacesOutput, error) {
+	f.record("CompareFaces", optFns)
+	if f.compareFaces != nil {
+		return f.compareFaces(params)
+	}
+	return &rekognition.CompareFacesOutput{}, nil
+}
+
+func (f *fakeRekognition) CreateCollection(ctx context.Context, params *rekognition.CreateCollectionInput, optFns ...func(*rekognition.Options)) (*rekognition.CreateCollectionOutput, error) {
+	f.record("CreateCollection", optFns)
+	if f.createCollection != nil {
+		return f.createCollection(params)
+	}
+	return &rekognition.CreateCollectionOutput{}, nil
+}
+
+func (f *fakeRekognition) CreateFaceLivenessSession(ctx context.Context, params *rekognition.CreateFaceLivenessSessionInput, optFns ...func(*rekognition.Options)) (*rekognition.CreateFaceLivenessSessionOutput, error) {
+	f.record("CreateFaceLivenessSession", optFns)
+	if f.createLiveness != nil {
+		return f.createLiveness(params)
+	}
+	return &rekognition.CreateFaceLivenessSessionOutput{SessionId: aws.String("session-1")}, nil
+}
+
+func (f *fakeRekognition) CreateUser(ctx context.Context, params *rekognition.CreateUserInput, optFns ...func(*rekognition.Options)) (*rekognition.CreateUserOutput, error) {
+	f.record("CreateUser", optFns)
+	if f.createUser != nil {
+		return f.createUser(params)
+	}
+	return &rekognition.CreateUserOutput{}, nil
+}
+
+func (f *fakeRekognition) DeleteFaces(ctx context.Context, params *rekognition.DeleteFacesInput, optFns ...func(*rekognition.Options)) (*rekognition.DeleteFacesOutput, error) {
+	f.record("DeleteFaces", optFns)
+	if f.deleteFaces != nil {
+		return f.deleteFaces(params)
+	}
+	return &rekognition.DeleteFacesOutput{DeletedFaces: params.FaceIds}, nil
+}
+
+func (f *fakeRekognition) DescribeCollection(ctx context.Context, params *rekognition.DescribeCollectionInput, optFns ...func(*rekognition.Options)) (*rekognition.DescribeCollectionOutput, error) {
+	f.record("DescribeCollection", optFns)
+	if f.describeCollection != nil {
+		return f.describeCollection(params)
+	}
+	return &rekognition.DescribeCollectionOutput{}, nil
+}
+
+func (f *fakeRekognition) DetectFaces(ctx context.Context, params *rekognition.DetectFacesInput, optFns ...func(*rekognition.Options)) (*rekognition.DetectFacesOutput, error) {
+	f.record("DetectFaces", optFns)
+	if f.detectFaces != nil {
+		return f.detectFaces(params)
+	}
+	return &rekognition.DetectFacesOutput{}, nil
+}
+
+func (f *fakeRekognition) GetFaceLivenessSessionResults(ctx context.Context, params *rekognition.GetFaceLivenessSessionResultsInput, optFns ...func(*rekognition.Options)) (*rekognition.GetFaceLivenessSessionResultsOutput, error) {
+	f.record("GetFaceLivenessSessionResults", optFns)
+	if f.getLivenessResults != nil {
+		return f.getLivenessResults(params)
+	}
+	return &rekognition.GetFaceLivenessSessionResultsOutput{SessionId: params.SessionId}, nil
+}
+
+func (f *fakeRekognition) IndexFaces(ctx context.Context, params *rekognition.IndexFacesInput, optFns ...func(*rekognition.Options)) (*rekognition.IndexFacesOutput, error) {
+	f.record("IndexFaces", optFns)
+	if f.indexFaces != nil {
+		return f.indexFaces(params)
+	}
+	return &rekognition.IndexFacesOutput{
+		FaceRecords: []types.FaceRecord{{
+			Face: &types.Face{
+				FaceId:          aws.String("face-1"),
+				ExternalImageId: params.ExternalImageId,
+				Confidence:      aws.Float32(99.9),
+			},
+		}},
+	}, nil
+}
+
+func (f *fakeRekognition) ListFaces(ctx context.Context, params *rekognition.ListFacesInput, optFns ...func(*rekognition.Options)) (*rekognition.ListFacesOutput, error) {
+	f.record("ListFaces", optFns)
+	if f.listFaces != nil {
+		return f.listFaces(params)
+	}
+	// The faces asked for by FaceId are stored
+	faces := make([]types.Face, 0, len(params.FaceIds))
+	for _, faceId := range params.FaceIds {
+		faces = append(faces, types.Face{FaceId: aws.String(faceId)})
+	}
+	return &rekognition.ListFacesOutput{Faces: faces}, nil
+}
+
+func (f *fakeRekognition) SearchFaces(ctx context.Context, params *rekognition.SearchFacesInput, optFns ...func(*rekognition.Options)) (*rekognition.SearchFacesOutput, error) {
+	f.record("SearchFaces", optFns)
+	if f.searchFaces != nil {
+		return f.searchFaces(params)
+	}
+	return &rekognition.SearchFacesOutput{}, nil
+}
+
+func (f *fakeRekognition) SearchFacesByImage(ctx context.Context, params *rekognition.SearchFacesByImageInput, optFns ...func(*rekognition.Options)) (*rekognition.SearchFacesByImageOutput, error) {
+	f.record("SearchFacesByImage", optFns)
+	if f.searchFacesByImage != nil {
+		return f.searchFacesByImage(params)
+	}
+	return &rekognition.SearchFacesByImageOutput{}, nil
+}
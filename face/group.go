@@ -0,0 +1,119 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+	"github.com/samber/lo"
+)
+
+// groupSearchCropScale grows each detected face before it is searched on its own, so
+// Rekognition still finds a face in the crop
+const groupSearchCropScale = 1.5
+
+// GroupSearchResult is the outcome of SearchGroupPhoto.
+type GroupSearchResult struct {
+	// Matched are the detected faces that matched the collection
+	Matched []MatchedFace `json:"matched"`
+	// Unmatched are the crops of the detected faces nobody in the collection matched
+	Unmatched []FaceCrop `json:"unmatched"`
+}
+
+// MatchedFace is a face detected in a photo and its matches in the collection.
+type MatchedFace struct {
+	BoundingBox types.BoundingBox `json:"boundingBox"`
+	Matches     []FaceMatchResult `json:"matches"`
+}
+
+// SearchGroupPhoto detects every face in a group photo and searches each one against the
+// collection, returning both the faces that matched and crops of those that didn't, e.g.
+// for a "tag friends" screen. Faces are ordered by bounding-box area, largest first, and
+// WithMinSimilarity decides what counts as a match.
+func (r *rekognitionFaceIndexer) SearchGroupPhoto(ctx context.Context, image []byte, collectionId string, opts ...CallOption) (GroupSearchResult, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return GroupSearchResult{}, err
+	}
+	o := newCallOptions(opts)
+	faces, err := r.detectFaces(ctx, image, nil, o)
+	if err != nil {
+		return GroupSearchResult{}, fmt.Errorf("failed to search group photo: %w", err)
+	}
+	faces = lo.Filter(faces, func(face types.FaceDetail, _ int) bool {
+		return face.BoundingBox != nil
+	})
+	sort.SliceStable(faces, func(i, j int) bool {
+		return boundingBoxArea(faces[i].BoundingBox) > boundingBoxArea(faces[j].BoundingBox)
+	})
+
+	// Bounding boxes refer to the upright image, so rotate before cropping
+	img, format, err := decodeUpright(image)
+	if err != nil {
+		return GroupSearchResult{}, fmt.Errorf("failed to search group photo: %w", err)
+	}
+
+	result := GroupSearchResult{Matched: []MatchedFace{}, Unmatched: []FaceCrop{}}
+	for _, face := range faces {
+		bbox := *face.BoundingBox
+		matches, err := r.searchFaceRegion(ctx, img, format, bbox, collectionId, 0, o)
+		if err != nil {
+			return GroupSearchResult{}, fmt.Errorf("failed to search face %s: %w", bboxString(bbox), err)
+		}
+		if len(matches) > 0 {
+			result.Matched = append(result.Matched, MatchedFace{BoundingBox: bbox, Matches: matches})
+			continue
+		}
+
+		cropped, err := CropFaceRegion(img, bbox, 1)
+		if err != nil {
+			return GroupSearchResult{}, fmt.Errorf("failed to crop face %s: %w", bboxString(bbox), err)
+		}
+		crop, err := o.encodeCrop(cropped, format)
+		if err != nil {
+			return GroupSearchResult{}, err
+		}
+		result.Unmatched = append(result.Unmatched, FaceCrop{BoundingBox: bbox, Crop: crop})
+	}
+	return result, noMatchError(len(result.Matched), o)
+}
+
+// searchFaceRegion searches the face in bbox on its own, with Rekognition's default threshold
+// when threshold is 0. A crop in which Rekognition finds no face has no matches rather than
+// failing the whole photo.
+func (r *rekognitionFaceIndexer) searchFaceRegion(ctx context.Context, img image.Image, format ImageFormat, bbox types.BoundingBox, collectionId string, threshold float32, o callOptions) ([]FaceMatchResult, error) {
+	cropped, err := CropFaceRegion(img, bbox, groupSearchCropScale)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := encodeImage(upscaleToMinimum(cropped), format)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.searchFacesByImage(ctx, &types.Image{Bytes: payload}, collectionId, threshold, o)
+	if err != nil {
+		var invalidParamErr *types.InvalidParameterException
+		if errors.As(err, &invalidParamErr) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return faceMatchResults(resp.FaceMatches, o), nil
+}
+
+// upscaleToMinimum enlarges img, keeping its aspect ratio, until both sides are at
+// least the smallest dimension Rekognition accepts, as small faces in group photos aren't
+func upscaleToMinimum(img image.Image) image.Image {
+	bounds := img.Bounds()
+	shortest := min(bounds.Dx(), bounds.Dy())
+	if shortest >= minImageDimension {
+		return img
+	}
+	factor := float64(minImageDimension) / float64(shortest)
+	width := max(minImageDimension, int(float64(bounds.Dx())*factor+0.5))
+	height := max(minImageDimension, int(float64(bounds.Dy())*factor+0.5))
+	return resizeImage(img, width, height)
+}
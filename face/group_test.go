@@ -0,0 +1,65 @@
+package face
+
+import (
+	"bytes"
+	"context"
+	"image/jpeg"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func TestSearchGroupPhoto(t *testing.T) {
+	var searchedWidths []int
+	fake := &fakeRekognition{
+		detectFaces: func(*rekognition.DetectFacesInput) (*rekognition.DetectFacesOutput, error) {
+			return &rekognition.DetectFacesOutput{FaceDetails: []types.FaceDetail{
+				faceDetail(boundingBox(0.7, 0.7, 0.1, 0.1)),
+				faceDetail(boundingBox(0.1, 0.1, 0.4, 0.4)),
+				faceDetail(boundingBox(0.6, 0.1, 0.2, 0.2)),
+			}}, nil
+		},
+		searchFacesByImage: func(input *rekognition.SearchFacesByImageInput) (*rekognition.SearchFacesByImageOutput, error) {
+			config, err := jpeg.DecodeConfig(bytes.NewReader(input.Image.Bytes))
+			if err != nil {
+				return nil, err
+			}
+			searchedWidths = append(searchedWidths, config.Width)
+			switch len(searchedWidths) {
+			case 1:
+				return &rekognition.SearchFacesByImageOutput{FaceMatches: []types.FaceMatch{faceMatch("face-alice", "photo_1", 99)}}, nil
+			case 2:
+				return &rekognition.SearchFacesByImageOutput{FaceMatches: []types.FaceMatch{faceMatch("face-bob", "photo_2", 60)}}, nil
+			default:
+				return nil, &types.InvalidParameterException{Message: aws.String("no face in the image")}
+			}
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	result, err := faceIndexer.SearchGroupPhoto(context.TODO(), testJPEG(t, 200, 200), "event_1", WithMinSimilarity(80))
+	if err != nil {
+		t.Fatalf("error searching group photo: %v", err)
+	}
+	if len(result.Matched) != 1 || result.Matched[0].Matches[0].FaceId != "face-alice" {
+		t.Fatalf("got matched %+v, want face-alice only", result.Matched)
+	}
+	if len(result.Unmatched) != 2 {
+		t.Fatalf("got %d unmatched faces, want 2", len(result.Unmatched))
+	}
+	config, err := jpeg.DecodeConfig(bytes.NewReader(result.Unmatched[1].Crop))
+	if err != nil {
+		t.Fatalf("error decoding unmatched crop: %v", err)
+	}
+	if config.Width != 20 {
+		t.Fatalf("unmatched crop is %dpx wide, want 20", config.Width)
+	}
+
+	// The smallest face is upscaled to the minimum size Rekognition accepts
+	if want := []int{120, 80, 80}; !reflect.DeepEqual(searchedWidths, want) {
+		t.Fatalf("searched crops %v px wide, want %v", searchedWidths, want)
+	}
+}
@@ -0,0 +1,79 @@
+package face
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+// jpegQuality is the quality used whenever the package re-encodes a JPEG
+const jpegQuality = 90
+
+// minImageDimension is the smallest width and height Rekognition accepts.
+const minImageDimension = 80
+
+// validateImage sniffs the image bytes before they are sent to Rekognition, so
+// callers get an actionable error instead of an InvalidImageFormatException
+// after a wasted round trip. It returns the detected format.
+func validateImage(imageBytes []byte) (string, error) {
+	if len(imageBytes) == 0 {
+		return "", ErrEmptyImage
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(imageBytes))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnsupportedImageFormat, err)
+	}
+	if format != "jpeg" && format != "png" {
+		return format, fmt.Errorf("%w: detected %s", ErrUnsupportedImageFormat, format)
+	}
+	if config.Width < minImageDimension || config.Height < minImageDimension {
+		return format, fmt.Errorf("%w: %dx%d is smaller than %dx%d", ErrInvalidImageDimensions, config.Width, config.Height, minImageDimension, minImageDimension)
+	}
+	return format, nil
+}
+
+// ImageFormat is the encoding of an image produced by the package.
+type ImageFormat string
+
+const (
+	FormatJPEG ImageFormat = "jpeg"
+	FormatPNG  ImageFormat = "png"
+)
+
+// decodeImage decodes JPEG or PNG bytes and reports the detected format
+func decodeImage(imageBytes []byte) (image.Image, ImageFormat, error) {
+	img, format, err := image.Decode(bytes.NewReader(imageBytes))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, ImageFormat(format), nil
+}
+
+// encodeImage encodes img as a PNG for PNG sources, keeping transparency, and as a JPEG otherwise.
+//
+// Every image the package returns (crops, annotated images, downscaled payloads) is encoded here
+// or in encodeJPEG from decoded pixels, so EXIF and other metadata such as GPS position or device
+// model never survive from the source. Keep it that way: never return source bytes as-is, even when
+// a crop covers the whole image.
+func encodeImage(img image.Image, format ImageFormat) ([]byte, error) {
+	if format != FormatPNG {
+		return encodeJPEG(img)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeJPEG encodes img as a JPEG, without any metadata
+func encodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode jpeg: %w", err)
+	}
+	return buf.Bytes(), nil
+}
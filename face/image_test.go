@@ -0,0 +1,69 @@
+package face
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func testJPEG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(width, height), nil); err != nil {
+		t.Fatalf("failed to encode jpeg: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(width, height)); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestValidateImage(t *testing.T) {
+	var gifBuf bytes.Buffer
+	if err := gif.Encode(&gifBuf, testImage(100, 100), nil); err != nil {
+		t.Fatalf("failed to encode gif: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		image   []byte
+		wantErr error
+	}{
+		{name: "jpeg", image: testJPEG(t, 100, 100)},
+		{name: "png", image: testPNG(t, 100, 100)},
+		{name: "gif", image: gifBuf.Bytes(), wantErr: ErrUnsupportedImageFormat},
+		{name: "corrupt", image: []byte("not an image"), wantErr: ErrUnsupportedImageFormat},
+		{name: "too small", image: testJPEG(t, 40, 40), wantErr: ErrInvalidImageDimensions},
+		{name: "empty", image: []byte{}, wantErr: ErrEmptyImage},
+		{name: "nil", image: nil, wantErr: ErrEmptyImage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validateImage(tt.image)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
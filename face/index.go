@@ -0,0 +1,267 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+	"github.com/samber/lo"
+)
+
+// IndexFaceAndCrop indexes the image and returns the FaceId of its most prominent face together
+// with a crop of that face, e.g. for a profile avatar. No search is done. scale grows the
+// crop around the face as in CropFaceRegion. The crop is re-encoded and carries no EXIF
+// metadata from the source, so it is safe for user-facing thumbnails.
+func (r *rekognitionFaceIndexer) IndexFaceAndCrop(ctx context.Context, image []byte, externalImageId string, collectionId string, scale float64, opts ...CallOption) (string, []byte, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return "", nil, err
+	}
+	o := newCallOptions(opts)
+	resp, err := r.indexFaceBytes(ctx, image, externalImageId, collectionId, o)
+	if err != nil {
+		return "", nil, err
+	}
+
+	record := lo.MaxBy(resp.FaceRecords, func(a, b types.FaceRecord) bool {
+		return boundingBoxArea(a.Face.BoundingBox) > boundingBoxArea(b.Face.BoundingBox)
+	})
+	faceId := aws.ToString(record.Face.FaceId)
+	if record.Face.BoundingBox == nil {
+		return faceId, nil, fmt.Errorf("failed to crop face %s: %w: missing coordinates", faceId, ErrInvalidBoundingBox)
+	}
+
+	// Bounding boxes refer to the upright image, so rotate before cropping
+	crop, err := cropFace(image, *record.Face.BoundingBox, scale, o)
+	if err != nil {
+		return faceId, nil, fmt.Errorf("failed to crop face %s: %w", faceId, err)
+	}
+	return faceId, crop, nil
+}
+
+// IndexSingleFaceOnly indexes the image only when it has exactly one face, for single-person
+// enrollment such as profile selfies. It detects faces first and returns ErrMultipleFaces
+// without indexing anything when there is more than one, so a bystander is never enrolled
+// under the user's profile.
+func (r *rekognitionFaceIndexer) IndexSingleFaceOnly(ctx context.Context, image []byte, externalImageId string, collectionId string, opts ...CallOption) error {
+	if err := validateCollectionId(collectionId); err != nil {
+		return err
+	}
+	o := newCallOptions(opts)
+	faces, err := r.detectFaces(ctx, image, nil, o)
+	if err != nil {
+		return fmt.Errorf("failed to index single face: %w", err)
+	}
+	switch {
+	case len(faces) == 0:
+		return fmt.Errorf("failed to index single face: %w", ErrNoFaceDetected)
+	case len(faces) > 1:
+		return fmt.Errorf("failed to index single face: %w: found %d", ErrMultipleFaces, len(faces))
+	}
+
+	_, err = r.indexFaceBytes(ctx, image, externalImageId, collectionId, o)
+	return err
+}
+
+// ReasonLowDetectionConfidence marks faces that were indexed below the
+// WithMinDetectionConfidence threshold and removed again.
+const ReasonLowDetectionConfidence types.Reason = "LOW_DETECTION_CONFIDENCE"
+
+// IndexFaceResult is the outcome of IndexFaceDetailed.
+type IndexFaceResult struct {
+	Faces   []IndexedFace `json:"faces"`
+	Skipped []SkippedFace `json:"skipped"`
+	// AttributesDowngraded is set when indexing with ALL detection attributes timed out and
+	// the image was indexed with the DEFAULT ones instead, so the faces lack the extra details
+	AttributesDowngraded bool `json:"attributesDowngraded"`
+}
+
+// SkippedFace is a face that was detected but not indexed, with the reasons why.
+type SkippedFace struct {
+	BoundingBox types.BoundingBox `json:"boundingBox"`
+	Confidence  float32           `json:"confidence"`
+	Reasons     []types.Reason    `json:"reasons"`
+}
+
+// IndexFaceDetailed is IndexFace returning the indexed faces, with their FaceIds and bounding
+// boxes, together with the detected faces that were skipped, either by Rekognition's quality
+// filter or by WithMinDetectionConfidence. The boxes can be drawn or cropped without another
+// DetectFaces call. When every face is skipped, the result lists them together with the
+// UnindexedFacesError.
+func (r *rekognitionFaceIndexer) IndexFaceDetailed(ctx context.Context, image []byte, externalImageId string, collectionId string, opts ...CallOption) (IndexFaceResult, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return IndexFaceResult{}, err
+	}
+	o := newCallOptions(opts)
+	resp, err := r.indexFaceBytes(ctx, image, externalImageId, collectionId, o)
+	if err != nil {
+		// Nothing was indexed, but the skipped faces still tell why
+		var unindexedErr *UnindexedFacesError
+		if errors.As(err, &unindexedErr) {
+			return IndexFaceResult{Faces: []IndexedFace{}, Skipped: skippedFaces(unindexedErr.UnindexedFaces)}, err
+		}
+		return IndexFaceResult{}, err
+	}
+
+	result := IndexFaceResult{
+		Faces:                make([]IndexedFace, 0, len(resp.FaceRecords)),
+		Skipped:              skippedFaces(resp.UnindexedFaces),
+		AttributesDowngraded: attributesDowngraded(resp),
+	}
+	for _, record := range resp.FaceRecords {
+		face := indexedFace(record)
+		if o.faceRecords {
+			face.Face, face.FaceDetail = record.Face, record.FaceDetail
+		}
+		result.Faces = append(result.Faces, face)
+	}
+	return result, nil
+}
+
+// skippedFaces converts the faces IndexFaces detected but didn't index
+func skippedFaces(unindexedFaces []types.UnindexedFace) []SkippedFace {
+	skippedFaces := make([]SkippedFace, 0, len(unindexedFaces))
+	for _, unindexed := range unindexedFaces {
+		skipped := SkippedFace{Reasons: unindexed.Reasons}
+		if unindexed.FaceDetail != nil {
+			skipped.Confidence = aws.ToFloat32(unindexed.FaceDetail.Confidence)
+			if unindexed.FaceDetail.BoundingBox != nil {
+				skipped.BoundingBox = *unindexed.FaceDetail.BoundingBox
+			}
+		}
+		skippedFaces = append(skippedFaces, skipped)
+	}
+	return skippedFaces
+}
+
+// attributesDowngradedKey marks, in the result metadata of IndexFaces, a call retried
+// with the DEFAULT detection attributes after timing out with ALL of them
+type attributesDowngradedKey struct{}
+
+func attributesDowngraded(resp *rekognition.IndexFacesOutput) bool {
+	downgraded, _ := resp.ResultMetadata.Get(attributesDowngradedKey{}).(bool)
+	return downgraded
+}
+
+// isTimeout reports whether err is a call that ran out of time rather than one Rekognition rejected
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// dropLowConfidenceFaces deletes the indexed faces below the minimum detection confidence and moves them from
+// the FaceRecords to the UnindexedFaces of resp
+func (r *rekognitionFaceIndexer) dropLowConfidenceFaces(ctx context.Context, resp *rekognition.IndexFacesOutput, collectionId string, o callOptions) error {
+	minConfidence := o.minDetectionConfidence
+	// Faces without any confidence can't be judged and are kept
+	kept, low := lo.FilterReject(resp.FaceRecords, func(record types.FaceRecord, _ int) bool {
+		confidence, ok := faceConfidence(record)
+		return !ok || confidence >= minConfidence
+	})
+	if len(low) == 0 {
+		return nil
+	}
+
+	_, err := invoke(ctx, r, "DeleteFaces", r.client.DeleteFaces, &rekognition.DeleteFacesInput{
+		CollectionId: aws.String(collectionId),
+		FaceIds: lo.Map(low, func(record types.FaceRecord, _ int) string {
+			return aws.ToString(record.Face.FaceId)
+		}),
+	}, o.apiOptions...)
+	if err != nil {
+		return fmt.Errorf("failed to remove faces below detection confidence %.1f: %w", minConfidence, err)
+	}
+
+	resp.FaceRecords = kept
+	for _, record := range low {
+		confidence, _ := faceConfidence(record)
+		detail := &types.FaceDetail{BoundingBox: record.Face.BoundingBox, Confidence: aws.Float32(confidence)}
+		if record.FaceDetail != nil {
+			detail = record.FaceDetail
+			detail.Confidence = aws.Float32(confidence)
+		}
+		resp.UnindexedFaces = append(resp.UnindexedFaces, types.UnindexedFace{
+			FaceDetail: detail,
+			Reasons:    []types.Reason{ReasonLowDetectionConfidence},
+		})
+	}
+	return nil
+}
+
+// indexedFace converts an IndexFaces record. The bounding box falls back to the FaceDetail's
+// and is left zero when the record has none.
+func indexedFace(record types.FaceRecord) IndexedFace {
+	face := IndexedFace{FaceId: aws.ToString(record.Face.FaceId)}
+	switch {
+	case record.Face.BoundingBox != nil:
+		face.BoundingBox = *record.Face.BoundingBox
+	case record.FaceDetail != nil && record.FaceDetail.BoundingBox != nil:
+		face.BoundingBox = *record.FaceDetail.BoundingBox
+	}
+	face.Confidence, _ = faceConfidence(record)
+	return face
+}
+
+// faceConfidence is the detection confidence of an indexed face. It prefers the Face and
+// falls back to the FaceDetail, where the confidence can be when detection attributes
+// are requested. ok is false when neither has one.
+func faceConfidence(record types.FaceRecord) (float32, bool) {
+	if record.Face != nil && record.Face.Confidence != nil {
+		return *record.Face.Confidence, true
+	}
+	if record.FaceDetail != nil && record.FaceDetail.Confidence != nil {
+		return *record.FaceDetail.Confidence, true
+	}
+	return 0, false
+}
+
+// ReplaceFace enrolls the image under externalImageId in place of the faces already indexed
+// under it, e.g. when a user uploads a better profile photo. The new image is indexed before
+// the old faces are deleted, so a failure never leaves the user without an enrolled face: when
+// indexing fails nothing is deleted, and when the delete fails both are kept and the new
+// FaceId is returned with the error. It returns the most prominent new face and the FaceIds
+// that were deleted.
+func (r *rekognitionFaceIndexer) ReplaceFace(ctx context.Context, image []byte, externalImageId string, collectionId string, opts ...CallOption) (string, []string, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return "", nil, err
+	}
+	o := newCallOptions(opts)
+	// Indexing must not short-circuit on the faces being replaced
+	o.idempotent = false
+
+	previous, err := r.listFacesByExternalImageId(ctx, collectionId, externalImageId, o)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to replace face: %w", err)
+	}
+
+	resp, err := r.indexFaceBytes(ctx, image, externalImageId, collectionId, o)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to replace face: %w", err)
+	}
+	record := lo.MaxBy(resp.FaceRecords, func(a, b types.FaceRecord) bool {
+		return boundingBoxArea(a.Face.BoundingBox) > boundingBoxArea(b.Face.BoundingBox)
+	})
+	faceId := aws.ToString(record.Face.FaceId)
+	if len(previous) == 0 {
+		return faceId, nil, nil
+	}
+
+	previousIds := lo.Map(previous, func(face types.Face, _ int) string {
+		return aws.ToString(face.FaceId)
+	})
+	summary, err := r.DeleteFacebyFaceIds(ctx, previousIds, collectionId, WithAPIOptions(o.apiOptions...))
+	if err != nil {
+		return faceId, nil, fmt.Errorf("failed to delete replaced faces: %w", err)
+	}
+	failed := lo.Map(summary.FailedFaces, func(face FailedFace, _ int) string {
+		return face.FaceId
+	})
+	deleted := lo.Without(previousIds, failed...)
+	if len(failed) > 0 {
+		return faceId, deleted, fmt.Errorf("failed to delete replaced faces %v", failed)
+	}
+	return faceId, deleted, nil
+}
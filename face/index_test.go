@@ -0,0 +1,415 @@
+package face
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+	"github.com/samber/lo"
+)
+
+func TestIndexFaceNoFaceIndexed(t *testing.T) {
+	fake := &fakeRekognition{
+		indexFaces: func(*rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			return &rekognition.IndexFacesOutput{
+				UnindexedFaces: []types.UnindexedFace{
+					{Reasons: []types.Reason{types.ReasonLowBrightness, types.ReasonLowSharpness}},
+					{Reasons: []types.Reason{types.ReasonLowSharpness}},
+				},
+			}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	ctx := context.TODO()
+	err := faceIndexer.IndexFace(ctx, testJPEG(t, 100, 100), "photo_1", "event_1")
+	if !errors.Is(err, ErrNoFaceIndexed) {
+		t.Fatalf("got error %v, want %v", err, ErrNoFaceIndexed)
+	}
+	var unindexedErr *UnindexedFacesError
+	if !errors.As(err, &unindexedErr) {
+		t.Fatalf("error %v is not an UnindexedFacesError", err)
+	}
+	if want := []types.Reason{types.ReasonLowBrightness, types.ReasonLowSharpness}; !reflect.DeepEqual(unindexedErr.Reasons, want) {
+		t.Fatalf("got reasons %v, want %v", unindexedErr.Reasons, want)
+	}
+
+	err = faceIndexer.IndexFaceWithBucket(ctx, "bucket", "key.jpg", "photo_1", "event_1")
+	if !errors.Is(err, ErrNoFaceIndexed) {
+		t.Fatalf("got error %v, want %v", err, ErrNoFaceIndexed)
+	}
+}
+
+func TestIndexFaceDetectionAttributes(t *testing.T) {
+	var gotAttributes [][]types.Attribute
+	fake := &fakeRekognition{
+		indexFaces: func(input *rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			gotAttributes = append(gotAttributes, input.DetectionAttributes)
+			return (&fakeRekognition{}).IndexFaces(context.TODO(), input)
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+	imageBytes := testJPEG(t, 100, 100)
+
+	ctx := context.TODO()
+	if err := faceIndexer.IndexFace(ctx, imageBytes, "photo_1", "event_1", WithDetectionAttributes(types.AttributeAll)); err != nil {
+		t.Fatalf("error indexing face: %v", err)
+	}
+	if err := faceIndexer.IndexFace(ctx, imageBytes, "photo_2", "event_1"); err != nil {
+		t.Fatalf("error indexing face: %v", err)
+	}
+
+	want := [][]types.Attribute{{types.AttributeAll}, nil}
+	if !reflect.DeepEqual(gotAttributes, want) {
+		t.Fatalf("got attributes %v, want %v", gotAttributes, want)
+	}
+}
+
+func TestIndexFaceDowngradesAttributesOnTimeout(t *testing.T) {
+	var gotAttributes [][]types.Attribute
+	fake := &fakeRekognition{
+		indexFaces: func(input *rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			gotAttributes = append(gotAttributes, input.DetectionAttributes)
+			if lo.Contains(input.DetectionAttributes, types.AttributeAll) {
+				return nil, context.DeadlineExceeded
+			}
+			return (&fakeRekognition{}).IndexFaces(context.TODO(), input)
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	result, err := faceIndexer.IndexFaceDetailed(context.TODO(), testJPEG(t, 100, 100), "photo_1", "event_1", WithDetectionAttributes(types.AttributeAll))
+	if err != nil {
+		t.Fatalf("error indexing face: %v", err)
+	}
+	if !result.AttributesDowngraded || len(result.Faces) != 1 {
+		t.Fatalf("got result %+v, want one face indexed with downgraded attributes", result)
+	}
+	want := [][]types.Attribute{{types.AttributeAll}, {types.AttributeDefault}}
+	if !reflect.DeepEqual(gotAttributes, want) {
+		t.Fatalf("got attributes %v, want %v", gotAttributes, want)
+	}
+
+	// Other failures aren't retried
+	fake.indexFaces = func(*rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+		return nil, &types.InvalidParameterException{Message: aws.String("bad request")}
+	}
+	_, err = faceIndexer.IndexFaceDetailed(context.TODO(), testJPEG(t, 100, 100), "photo_2", "event_1", WithDetectionAttributes(types.AttributeAll))
+	if err == nil || fake.count("IndexFaces") != 3 {
+		t.Fatalf("got error %v after %d IndexFaces calls, want an error after 3", err, fake.count("IndexFaces"))
+	}
+}
+
+func TestIndexFaceAndCrop(t *testing.T) {
+	fake := &fakeRekognition{
+		indexFaces: func(input *rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			small := boundingBox(0, 0, 0.1, 0.1)
+			large := boundingBox(0.5, 0.25, 0.5, 0.5)
+			return &rekognition.IndexFacesOutput{
+				FaceRecords: []types.FaceRecord{
+					{Face: &types.Face{FaceId: aws.String("face-small"), BoundingBox: &small, Confidence: aws.Float32(99)}},
+					{Face: &types.Face{FaceId: aws.String("face-large"), BoundingBox: &large, Confidence: aws.Float32(99)}},
+				},
+			}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	// Stored as 200x100 but displayed rotated, 100x200
+	imageBytes := withExifOrientation(testJPEG(t, 200, 100), orientationRotate90)
+
+	faceId, crop, err := faceIndexer.IndexFaceAndCrop(context.TODO(), imageBytes, "photo_1", "event_1", 1)
+	if err != nil {
+		t.Fatalf("error indexing face: %v", err)
+	}
+	if faceId != "face-large" {
+		t.Fatalf("got FaceId %s, want face-large", faceId)
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(crop))
+	if err != nil {
+		t.Fatalf("error decoding crop: %v", err)
+	}
+	if format != "jpeg" || config.Width != 50 || config.Height != 100 {
+		t.Fatalf("got %s crop of %dx%d, want 50x100 jpeg", format, config.Width, config.Height)
+	}
+}
+
+func TestIndexFaceMinDetectionConfidence(t *testing.T) {
+	var deleted []string
+	fake := &fakeRekognition{
+		indexFaces: func(*rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			face := boundingBox(0.1, 0.1, 0.3, 0.3)
+			artifact := boundingBox(0.6, 0.6, 0.1, 0.1)
+			return &rekognition.IndexFacesOutput{
+				FaceRecords: []types.FaceRecord{
+					{Face: &types.Face{FaceId: aws.String("face-1"), BoundingBox: &face, Confidence: aws.Float32(99.9)}},
+					{Face: &types.Face{FaceId: aws.String("face-2"), BoundingBox: &artifact, Confidence: aws.Float32(91)}},
+				},
+			}, nil
+		},
+		deleteFaces: func(input *rekognition.DeleteFacesInput) (*rekognition.DeleteFacesOutput, error) {
+			deleted = append(deleted, input.FaceIds...)
+			return &rekognition.DeleteFacesOutput{DeletedFaces: input.FaceIds}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	ctx := context.TODO()
+	result, err := faceIndexer.IndexFaceDetailed(ctx, testJPEG(t, 100, 100), "photo_1", "event_1", WithMinDetectionConfidence(99))
+	if err != nil {
+		t.Fatalf("error indexing face: %v", err)
+	}
+	want := IndexFaceResult{
+		Faces: []IndexedFace{{FaceId: "face-1", BoundingBox: boundingBox(0.1, 0.1, 0.3, 0.3), Confidence: 99.9}},
+		Skipped: []SkippedFace{{
+			BoundingBox: boundingBox(0.6, 0.6, 0.1, 0.1),
+			Confidence:  91,
+			Reasons:     []types.Reason{ReasonLowDetectionConfidence},
+		}},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Fatalf("got result %+v, want %+v", result, want)
+	}
+	if !reflect.DeepEqual(deleted, []string{"face-2"}) {
+		t.Fatalf("deleted %v, want [face-2]", deleted)
+	}
+
+	// Every face below the threshold leaves nothing indexed
+	err = faceIndexer.IndexFace(ctx, testJPEG(t, 100, 100), "photo_2", "event_1", WithMinDetectionConfidence(99.95))
+	var unindexedErr *UnindexedFacesError
+	if !errors.As(err, &unindexedErr) || !reflect.DeepEqual(unindexedErr.Reasons, []types.Reason{ReasonLowDetectionConfidence}) {
+		t.Fatalf("got error %v, want faces rejected for %s", err, ReasonLowDetectionConfidence)
+	}
+
+	// The skipped faces are still reported with the error
+	result, err = faceIndexer.IndexFaceDetailed(ctx, testJPEG(t, 100, 100), "photo_3", "event_1", WithMinDetectionConfidence(99.95))
+	if !errors.Is(err, ErrNoFaceIndexed) {
+		t.Fatalf("got error %v, want %v", err, ErrNoFaceIndexed)
+	}
+	if len(result.Faces) != 0 || len(result.Skipped) != 2 || result.Skipped[0].Confidence != 99.9 || !reflect.DeepEqual(result.Skipped[1], want.Skipped[0]) {
+		t.Fatalf("got result %+v, want both faces skipped", result)
+	}
+}
+
+func TestFaceConfidence(t *testing.T) {
+	tests := []struct {
+		name   string
+		record types.FaceRecord
+		want   float32
+		wantOk bool
+	}{
+		{"face", types.FaceRecord{Face: &types.Face{Confidence: aws.Float32(99)}, FaceDetail: &types.FaceDetail{Confidence: aws.Float32(98)}}, 99, true},
+		{"face detail", types.FaceRecord{Face: &types.Face{}, FaceDetail: &types.FaceDetail{Confidence: aws.Float32(98)}}, 98, true},
+		{"unknown", types.FaceRecord{Face: &types.Face{}}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := faceConfidence(tt.record)
+			if got != tt.want || ok != tt.wantOk {
+				t.Fatalf("got %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+
+	// A record without Face.Confidence no longer panics when indexed
+	fake := &fakeRekognition{
+		indexFaces: func(*rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			return &rekognition.IndexFacesOutput{FaceRecords: []types.FaceRecord{{Face: &types.Face{FaceId: aws.String("face-1")}}}}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+	if err := faceIndexer.IndexFace(context.TODO(), testJPEG(t, 100, 100), "photo_1", "event_1"); err != nil {
+		t.Fatalf("error indexing face: %v", err)
+	}
+}
+
+func TestIndexSingleFaceOnly(t *testing.T) {
+	tests := []struct {
+		name    string
+		faces   []types.FaceDetail
+		wantErr error
+	}{
+		{"one face", []types.FaceDetail{faceDetail(boundingBox(0.1, 0.1, 0.3, 0.3))}, nil},
+		{"no face", nil, ErrNoFaceDetected},
+		{"two faces", []types.FaceDetail{
+			faceDetail(boundingBox(0.1, 0.1, 0.3, 0.3)),
+			faceDetail(boundingBox(0.6, 0.6, 0.1, 0.1)),
+		}, ErrMultipleFaces},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeRekognition{
+				detectFaces: func(*rekognition.DetectFacesInput) (*rekognition.DetectFacesOutput, error) {
+					return &rekognition.DetectFacesOutput{FaceDetails: tt.faces}, nil
+				},
+			}
+			faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+			err := faceIndexer.IndexSingleFaceOnly(context.TODO(), testJPEG(t, 100, 100), "profile_1", "users")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			wantIndexed := 0
+			if tt.wantErr == nil {
+				wantIndexed = 1
+			}
+			if got := fake.count("IndexFaces"); got != wantIndexed {
+				t.Fatalf("got %d IndexFaces calls, want %d", got, wantIndexed)
+			}
+		})
+	}
+}
+
+func TestIndexFaceIdempotent(t *testing.T) {
+	var indexed []types.Face
+	fake := &fakeRekognition{}
+	fake.indexFaces = func(input *rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+		face := types.Face{FaceId: aws.String(fmt.Sprintf("face-%d", len(indexed)+1)), ExternalImageId: input.ExternalImageId}
+		indexed = append(indexed, face)
+		return &rekognition.IndexFacesOutput{FaceRecords: []types.FaceRecord{{Face: &face}}}, nil
+	}
+	fake.listFaces = func(*rekognition.ListFacesInput) (*rekognition.ListFacesOutput, error) {
+		return &rekognition.ListFacesOutput{Faces: indexed}, nil
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+	imageBytes := testJPEG(t, 100, 100)
+
+	ctx := context.TODO()
+	for i := 0; i < 2; i++ {
+		result, err := faceIndexer.IndexFaceDetailed(ctx, imageBytes, "photo_1", "event_1", WithIdempotentIndex())
+		if err != nil {
+			t.Fatalf("error indexing face: %v", err)
+		}
+		if len(result.Faces) != 1 || result.Faces[0].FaceId != "face-1" {
+			t.Fatalf("attempt %d got faces %+v, want face-1", i+1, result.Faces)
+		}
+	}
+	if got := fake.count("IndexFaces"); got != 1 {
+		t.Fatalf("got %d IndexFaces calls, want 1", got)
+	}
+
+	// Without the option a retry indexes again
+	if err := faceIndexer.IndexFace(ctx, imageBytes, "photo_1", "event_1"); err != nil {
+		t.Fatalf("error indexing face: %v", err)
+	}
+	if got := fake.count("IndexFaces"); got != 2 {
+		t.Fatalf("got %d IndexFaces calls, want 2", got)
+	}
+}
+
+func TestIndexFaceDetailedBoundingBoxes(t *testing.T) {
+	fake := &fakeRekognition{
+		indexFaces: func(*rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			first := boundingBox(0.1, 0.1, 0.2, 0.2)
+			second := boundingBox(0.5, 0.2, 0.2, 0.2)
+			return &rekognition.IndexFacesOutput{
+				FaceRecords: []types.FaceRecord{
+					{Face: &types.Face{FaceId: aws.String("face-1"), BoundingBox: &first}},
+					// Only the FaceDetail has the box
+					{Face: &types.Face{FaceId: aws.String("face-2")}, FaceDetail: &types.FaceDetail{BoundingBox: &second}},
+					{Face: &types.Face{FaceId: aws.String("face-3")}},
+				},
+			}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	result, err := faceIndexer.IndexFaceDetailed(context.TODO(), testJPEG(t, 100, 100), "photo_1", "event_1")
+	if err != nil {
+		t.Fatalf("error indexing face: %v", err)
+	}
+	want := []IndexedFace{
+		{FaceId: "face-1", BoundingBox: boundingBox(0.1, 0.1, 0.2, 0.2)},
+		{FaceId: "face-2", BoundingBox: boundingBox(0.5, 0.2, 0.2, 0.2)},
+		{FaceId: "face-3"},
+	}
+	if !reflect.DeepEqual(result.Faces, want) {
+		t.Fatalf("got faces %+v, want %+v", result.Faces, want)
+	}
+}
+
+func TestIndexFaceDetailedFaceRecords(t *testing.T) {
+	detail := &types.FaceDetail{Sunglasses: &types.Sunglasses{Value: true}}
+	fake := &fakeRekognition{
+		indexFaces: func(*rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			return &rekognition.IndexFacesOutput{
+				FaceRecords: []types.FaceRecord{
+					{Face: &types.Face{FaceId: aws.String("face-1")}, FaceDetail: detail},
+					{Face: &types.Face{FaceId: aws.String("face-2")}},
+				},
+			}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	result, err := faceIndexer.IndexFaceDetailed(context.TODO(), testJPEG(t, 100, 100), "photo_1", "event_1", WithFaceRecords())
+	if err != nil {
+		t.Fatalf("error indexing face: %v", err)
+	}
+	if len(result.Faces) != 2 || result.Faces[0].FaceDetail != detail || aws.ToString(result.Faces[1].Face.FaceId) != "face-2" || result.Faces[1].FaceDetail != nil {
+		t.Fatalf("got faces %+v, want both face records", result.Faces)
+	}
+
+	// Left out by default
+	result, err = faceIndexer.IndexFaceDetailed(context.TODO(), testJPEG(t, 100, 100), "photo_1", "event_1")
+	if err != nil {
+		t.Fatalf("error indexing face: %v", err)
+	}
+	if result.Faces[0].Face != nil || result.Faces[0].FaceDetail != nil {
+		t.Fatalf("got faces %+v, want no face records", result.Faces)
+	}
+}
+
+func TestReplaceFace(t *testing.T) {
+	stored := []types.Face{
+		{FaceId: aws.String("face-old-1"), ExternalImageId: aws.String("user_1")},
+		{FaceId: aws.String("face-other"), ExternalImageId: aws.String("user_2")},
+		{FaceId: aws.String("face-old-2"), ExternalImageId: aws.String("user_1")},
+	}
+	indexErr := error(nil)
+	var deleted []string
+	fake := &fakeRekognition{
+		listFaces: pagedListFaces(stored, 10),
+		indexFaces: func(input *rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			if indexErr != nil {
+				return nil, indexErr
+			}
+			bbox := boundingBox(0.2, 0.2, 0.5, 0.5)
+			return &rekognition.IndexFacesOutput{
+				FaceRecords: []types.FaceRecord{{Face: &types.Face{FaceId: aws.String("face-new"), BoundingBox: &bbox}}},
+			}, nil
+		},
+		deleteFaces: func(input *rekognition.DeleteFacesInput) (*rekognition.DeleteFacesOutput, error) {
+			deleted = append(deleted, input.FaceIds...)
+			return &rekognition.DeleteFacesOutput{DeletedFaces: input.FaceIds}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	ctx := context.TODO()
+	// A failed index keeps the old faces
+	indexErr = awsOperationError("IndexFaces", "req-1", 500, &types.InternalServerError{})
+	if _, _, err := faceIndexer.ReplaceFace(ctx, testJPEG(t, 100, 100), "user_1", "event_1"); err == nil {
+		t.Fatal("expected the failed index to fail the replace")
+	}
+	if len(deleted) != 0 {
+		t.Fatalf("deleted %v after a failed index", deleted)
+	}
+
+	indexErr = nil
+	newFaceId, deletedFaceIds, err := faceIndexer.ReplaceFace(ctx, testJPEG(t, 100, 100), "user_1", "event_1", WithIdempotentIndex())
+	if err != nil {
+		t.Fatalf("error replacing face: %v", err)
+	}
+	want := []string{"face-old-1", "face-old-2"}
+	if newFaceId != "face-new" || !reflect.DeepEqual(deletedFaceIds, want) || !reflect.DeepEqual(deleted, want) {
+		t.Fatalf("got %s, deleted %v (%v), want face-new replacing %v", newFaceId, deletedFaceIds, deleted, want)
+	}
+}
@@ -0,0 +1,46 @@
+package face
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+)
+
+// invoke calls a Rekognition operation with the indexer's per-operation
+// defaults applied, wrapping any error with the operation name and the AWS
+// request ID. optFns are forwarded to the SDK, see WithAPIOptions. Every AWS
+// call made by the indexer goes through it, after waiting for the rate limiter
+// of WithRateLimit.
+func invoke[In, Out any](ctx context.Context, r *rekognitionFaceIndexer, operation string, call func(context.Context, *In, ...func(*rekognition.Options)) (*Out, error), input *In, optFns ...func(*rekognition.Options)) (*Out, error) {
+	// Wait for the rate limiter before the operation timeout starts
+	if r.options.limiter != nil {
+		if err := r.options.limiter.Wait(ctx); err != nil {
+			return nil, awsError(operation, err)
+		}
+	}
+
+	if r.options.metrics != nil {
+		optFns = append(optFns[:len(optFns):len(optFns)], throttleRetryMetrics(r.options.metrics, operation))
+	}
+
+	ctx, cancel := r.operationContext(ctx)
+	defer cancel()
+
+	resp, err := call(ctx, input, optFns...)
+	if err != nil {
+		return nil, awsError(operation, err)
+	}
+	return resp, nil
+}
+
+// operationContext bounds a single AWS call by the default operation timeout
+// when one is configured and the caller's context has no deadline of its own.
+func (r *rekognitionFaceIndexer) operationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.options.defaultOperationTimeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, r.options.defaultOperationTimeout)
+}
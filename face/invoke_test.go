@@ -0,0 +1,109 @@
+package face
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+)
+
+func TestDefaultOperationTimeout(t *testing.T) {
+	var remaining time.Duration
+	var hasDeadline bool
+	fake := &fakeRekognition{}
+	faceIndexer := &rekognitionFaceIndexer{
+		client:  fake,
+		options: newOptions([]Option{WithDefaultOperationTimeout(time.Minute)}),
+	}
+	describe := func(ctx context.Context, input *rekognition.DescribeCollectionInput, optFns ...func(*rekognition.Options)) (*rekognition.DescribeCollectionOutput, error) {
+		var deadline time.Time
+		deadline, hasDeadline = ctx.Deadline()
+		remaining = time.Until(deadline)
+		return fake.DescribeCollection(ctx, input, optFns...)
+	}
+
+	// No deadline on the caller's context: the default applies
+	if _, err := invoke(context.TODO(), faceIndexer, "DescribeCollection", describe, &rekognition.DescribeCollectionInput{}); err != nil {
+		t.Fatalf("error invoking: %v", err)
+	}
+	if !hasDeadline || remaining > time.Minute || remaining < 50*time.Second {
+		t.Fatalf("got deadline %v (set %v), want about a minute", remaining, hasDeadline)
+	}
+
+	// The caller's deadline wins
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Hour)
+	defer cancel()
+	if _, err := invoke(ctx, faceIndexer, "DescribeCollection", describe, &rekognition.DescribeCollectionInput{}); err != nil {
+		t.Fatalf("error invoking: %v", err)
+	}
+	if remaining < 59*time.Minute {
+		t.Fatalf("got deadline %v, want the caller's hour", remaining)
+	}
+}
+
+func TestWithAPIOptions(t *testing.T) {
+	fake := &fakeRekognition{}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+	addHeader := func(*rekognition.Options) {}
+
+	ctx := context.TODO()
+	if err := faceIndexer.IndexFace(ctx, testJPEG(t, 100, 100), "photo_1", "event_1", WithAPIOptions(addHeader)); err != nil {
+		t.Fatalf("error indexing face: %v", err)
+	}
+	for _, op := range []string{"DescribeCollection", "IndexFaces"} {
+		if got := fake.countWithOpts(op); got != 1 {
+			t.Fatalf("got %d %s calls with options, want 1", got, op)
+		}
+	}
+
+	if err := faceIndexer.IndexFace(ctx, testJPEG(t, 100, 100), "photo_2", "event_1"); err != nil {
+		t.Fatalf("error indexing face: %v", err)
+	}
+	if got := fake.countWithOpts("IndexFaces"); got != 1 {
+		t.Fatalf("got %d IndexFaces calls with options, want only the first", got)
+	}
+}
+
+func TestWithAPIOptionsIdempotentIndex(t *testing.T) {
+	fake := &fakeRekognition{}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+	addHeader := func(*rekognition.Options) {}
+
+	if err := faceIndexer.IndexFace(context.TODO(), testJPEG(t, 100, 100), "photo_1", "event_1", WithIdempotentIndex(), WithAPIOptions(addHeader)); err != nil {
+		t.Fatalf("error indexing face: %v", err)
+	}
+	if got := fake.countWithOpts("ListFaces"); got != 1 {
+		t.Fatalf("got %d ListFaces calls with options, want 1", got)
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	fake := &fakeRekognition{}
+	faceIndexer := &rekognitionFaceIndexer{
+		client:  fake,
+		options: newOptions([]Option{WithRateLimit(20, 1)}),
+	}
+
+	// One call immediately, then one every 50ms across operations
+	start := time.Now()
+	ctx := context.TODO()
+	for i := 0; i < 3; i++ {
+		if _, err := faceIndexer.FaceExists(ctx, "event_1", "face-1"); err != nil {
+			t.Fatalf("error checking face: %v", err)
+		}
+		if _, _, err := faceIndexer.EstimateCollectionStorage(ctx, "event_1"); err != nil {
+			t.Fatalf("error estimating storage: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Fatalf("6 calls took %v, want at least 250ms at 20 per second", elapsed)
+	}
+
+	// The wait honors the caller's context
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	if _, err := faceIndexer.FaceExists(ctx, "event_1", "face-1"); err == nil {
+		t.Fatal("got no error waiting with a canceled context")
+	}
+}
@@ -0,0 +1,137 @@
+package face
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+	"github.com/samber/lo"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+// Landmark is a facial landmark, such as an eye or the nose, of a detected face.
+type Landmark struct {
+	Type types.LandmarkType `json:"type"`
+	// X and Y are normalized (0-1) to the upright image, as Rekognition returns them
+	X float32 `json:"x"`
+	Y float32 `json:"y"`
+	// PixelX and PixelY are the same point in pixels of the upright image
+	PixelX int `json:"pixelX"`
+	PixelY int `json:"pixelY"`
+}
+
+// FaceLandmarks returns the landmarks of the most prominent face in the image, e.g. to
+// align it before cropping.
+func (r *rekognitionFaceIndexer) FaceLandmarks(ctx context.Context, image []byte, opts ...CallOption) ([]Landmark, error) {
+	face, err := r.detectLargestFace(ctx, image, newCallOptions(opts))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get face landmarks: %w", err)
+	}
+
+	// Landmarks refer to the upright image, so measure it rotated
+	img, _, err := decodeUpright(image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get face landmarks: %w", err)
+	}
+	bounds := img.Bounds()
+	return lo.Map(face.Landmarks, func(landmark types.Landmark, _ int) Landmark {
+		x, y := aws.ToFloat32(landmark.X), aws.ToFloat32(landmark.Y)
+		return Landmark{
+			Type:   landmark.Type,
+			X:      x,
+			Y:      y,
+			PixelX: int(x * float32(bounds.Dx())),
+			PixelY: int(y * float32(bounds.Dy())),
+		}
+	}), nil
+}
+
+// AlignedFaceCrop crops the most prominent face like IndexFaceAndCrop, but first rotates the
+// image around the face so the eyes are horizontal, which gives uniform avatars. When
+// Rekognition returns no eye landmarks the face is cropped as it is.
+func (r *rekognitionFaceIndexer) AlignedFaceCrop(ctx context.Context, image []byte, scale float64, opts ...CallOption) ([]byte, error) {
+	o := newCallOptions(opts)
+	face, err := r.detectLargestFace(ctx, image, o)
+	if err != nil {
+		return nil, fmt.Errorf("failed to crop aligned face: %w", err)
+	}
+
+	// Bounding boxes refer to the upright image, so rotate before cropping
+	img, format, err := decodeUpright(image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to crop aligned face: %w", err)
+	}
+
+	angle := eyeAngle(face.Landmarks, img.Bounds())
+	if angle == 0 {
+		cropped, err := CropFaceRegion(img, *face.BoundingBox, scale)
+		if err != nil {
+			return nil, fmt.Errorf("failed to crop aligned face: %w", err)
+		}
+		return o.encodeCrop(cropped, format)
+	}
+
+	if scale <= 0 {
+		scale = 1
+	}
+	bounds := img.Bounds()
+	rect := scaledRect(bounds, *face.BoundingBox, scale).Intersect(bounds)
+	if rect.Empty() {
+		return nil, fmt.Errorf("failed to crop aligned face: %w: box %v is outside the %dx%d image", ErrInvalidBoundingBox, bboxString(*face.BoundingBox), bounds.Dx(), bounds.Dy())
+	}
+	return o.encodeCrop(rotatedCrop(img, rect, angle), format)
+}
+
+// detectLargestFace returns the most prominent face with its landmarks
+func (r *rekognitionFaceIndexer) detectLargestFace(ctx context.Context, image []byte, o callOptions) (types.FaceDetail, error) {
+	faces, err := r.detectFaces(ctx, image, []types.Attribute{types.AttributeAll}, o)
+	if err != nil {
+		return types.FaceDetail{}, err
+	}
+	faces = lo.Filter(faces, func(face types.FaceDetail, _ int) bool {
+		return face.BoundingBox != nil
+	})
+	if len(faces) == 0 {
+		return types.FaceDetail{}, ErrNoFaceDetected
+	}
+	return largestFace(faces), nil
+}
+
+// eyeAngle is the angle in radians of the line from the leftmost eye to the other one in
+// the pixels of bounds, positive when it slopes down, or 0 when an eye is missing. The
+// landmarks are normalized to the image, so they are scaled back first: on a non-square
+// image the normalized slope is not the real one.
+func eyeAngle(landmarks []types.Landmark, bounds image.Rectangle) float64 {
+	left, okLeft := lo.Find(landmarks, func(landmark types.Landmark) bool { return landmark.Type == types.LandmarkTypeEyeLeft })
+	right, okRight := lo.Find(landmarks, func(landmark types.Landmark) bool { return landmark.Type == types.LandmarkTypeEyeRight })
+	if !okLeft || !okRight {
+		return 0
+	}
+	if aws.ToFloat32(left.X) > aws.ToFloat32(right.X) {
+		left, right = right, left
+	}
+	dx := float64(aws.ToFloat32(right.X)-aws.ToFloat32(left.X)) * float64(bounds.Dx())
+	dy := float64(aws.ToFloat32(right.Y)-aws.ToFloat32(left.Y)) * float64(bounds.Dy())
+	return math.Atan2(dy, dx)
+}
+
+// rotatedCrop rotates img by -angle around the center of rect and copies rect out of the result,
+// so a line at angle through the center comes out horizontal
+func rotatedCrop(img image.Image, rect image.Rectangle, angle float64) image.Image {
+	cos, sin := math.Cos(angle), math.Sin(angle)
+	centerX := float64(rect.Min.X+rect.Max.X) / 2
+	centerY := float64(rect.Min.Y+rect.Max.Y) / 2
+
+	// Maps source pixels to the crop: rotate by -angle around the center, then move rect to the origin
+	sourceToCrop := f64.Aff3{
+		cos, sin, centerX - cos*centerX - sin*centerY - float64(rect.Min.X),
+		-sin, cos, centerY + sin*centerX - cos*centerY - float64(rect.Min.Y),
+	}
+	cropped := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	xdraw.ApproxBiLinear.Transform(cropped, sourceToCrop, img, img.Bounds(), xdraw.Src, nil)
+	return cropped
+}
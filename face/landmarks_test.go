@@ -0,0 +1,125 @@
+package face
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+// tiltedEyes is a white 200x200 PNG with black squares as eyes at (70, 80) and (130, 110)
+func tiltedEyes(t *testing.T) []byte {
+	return eyesPNG(t, 200, 200, image.Point{70, 80}, image.Point{130, 110})
+}
+
+// eyesPNG is a white width x height PNG with black squares as eyes at the given points
+func eyesPNG(t *testing.T, width, height int, eyes ...image.Point) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	for _, eye := range eyes {
+		draw.Draw(img, image.Rect(eye.X-4, eye.Y-4, eye.X+4, eye.Y+4), image.Black, image.Point{}, draw.Src)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func landmarkFace() *fakeRekognition {
+	return eyeLandmarkFace(boundingBox(0.25, 0.25, 0.5, 0.5), [2]float32{0.35, 0.4}, [2]float32{0.65, 0.55})
+}
+
+// eyeLandmarkFace detects one face in bbox with its eyes at the normalized points left and right
+func eyeLandmarkFace(bbox types.BoundingBox, left, right [2]float32) *fakeRekognition {
+	face := faceDetail(bbox)
+	face.Landmarks = []types.Landmark{
+		{Type: types.LandmarkTypeEyeRight, X: aws.Float32(right[0]), Y: aws.Float32(right[1])},
+		{Type: types.LandmarkTypeEyeLeft, X: aws.Float32(left[0]), Y: aws.Float32(left[1])},
+	}
+	return &fakeRekognition{
+		detectFaces: func(*rekognition.DetectFacesInput) (*rekognition.DetectFacesOutput, error) {
+			return &rekognition.DetectFacesOutput{FaceDetails: []types.FaceDetail{face}}, nil
+		},
+	}
+}
+
+func TestFaceLandmarks(t *testing.T) {
+	faceIndexer := &rekognitionFaceIndexer{client: landmarkFace()}
+
+	landmarks, err := faceIndexer.FaceLandmarks(context.TODO(), tiltedEyes(t))
+	if err != nil {
+		t.Fatalf("error getting landmarks: %v", err)
+	}
+	want := Landmark{Type: types.LandmarkTypeEyeRight, X: 0.65, Y: 0.55, PixelX: 130, PixelY: 110}
+	if len(landmarks) != 2 || landmarks[0] != want {
+		t.Fatalf("got landmarks %+v, want %+v first", landmarks, want)
+	}
+}
+
+func TestAlignedFaceCrop(t *testing.T) {
+	faceIndexer := &rekognitionFaceIndexer{client: landmarkFace()}
+
+	crop, err := faceIndexer.AlignedFaceCrop(context.TODO(), tiltedEyes(t), 1)
+	if err != nil {
+		t.Fatalf("error cropping aligned face: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(crop))
+	if err != nil {
+		t.Fatalf("error decoding crop: %v", err)
+	}
+	if img.Bounds().Dx() != 100 || img.Bounds().Dy() != 100 {
+		t.Fatalf("got %v crop, want 100x100", img.Bounds())
+	}
+
+	// The eyes end up on the same row
+	leftY, rightY := darkCenterY(img, 0, 50), darkCenterY(img, 50, 100)
+	if math.IsNaN(leftY) || math.IsNaN(rightY) || math.Abs(leftY-rightY) > 1.5 {
+		t.Fatalf("eyes at rows %.1f and %.1f, want them level", leftY, rightY)
+	}
+}
+
+func TestAlignedFaceCropNonSquare(t *testing.T) {
+	// In pixels the eyes slope by 20 over 100, but by 0.1 over 0.25 normalized
+	faceIndexer := &rekognitionFaceIndexer{client: eyeLandmarkFace(boundingBox(0.25, 0.3, 0.5, 0.4), [2]float32{0.375, 0.45}, [2]float32{0.625, 0.55})}
+
+	crop, err := faceIndexer.AlignedFaceCrop(context.TODO(), eyesPNG(t, 400, 200, image.Point{150, 90}, image.Point{250, 110}), 1)
+	if err != nil {
+		t.Fatalf("error cropping aligned face: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(crop))
+	if err != nil {
+		t.Fatalf("error decoding crop: %v", err)
+	}
+	if img.Bounds().Dx() != 200 || img.Bounds().Dy() != 80 {
+		t.Fatalf("got %v crop, want 200x80", img.Bounds())
+	}
+
+	leftY, rightY := darkCenterY(img, 0, 100), darkCenterY(img, 100, 200)
+	if math.IsNaN(leftY) || math.IsNaN(rightY) || math.Abs(leftY-rightY) > 1.5 {
+		t.Fatalf("eyes at rows %.1f and %.1f, want them level", leftY, rightY)
+	}
+}
+
+// darkCenterY is the mean row of the dark pixels between columns fromX and toX
+func darkCenterY(img image.Image, fromX, toX int) float64 {
+	var sum, count float64
+	for y := img.Bounds().Min.Y; y < img.Bounds().Max.Y; y++ {
+		for x := fromX; x < toX; x++ {
+			if gray := color.GrayModel.Convert(img.At(x, y)).(color.Gray); gray.Y < 64 {
+				sum += float64(y)
+				count++
+			}
+		}
+	}
+	return sum / count
+}
@@ -0,0 +1,76 @@
+package face
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+// LivenessSessionOptions configures a Face Liveness session. All fields are optional.
+type LivenessSessionOptions struct {
+	// ClientRequestToken makes session creation idempotent.
+	ClientRequestToken string
+	// KmsKeyId encrypts the reference and audit images stored in S3.
+	KmsKeyId string
+	// AuditImagesLimit is the number of audit images returned, 0 to 4.
+	AuditImagesLimit int32
+	// OutputS3Bucket stores the reference and audit images in S3 instead of
+	// returning them as bytes.
+	OutputS3Bucket    string
+	OutputS3KeyPrefix string
+}
+
+// CreateLivenessSession starts a Face Liveness session and returns its id. The
+// session is completed by the client side Amplify FaceLivenessDetector.
+func (r *rekognitionFaceIndexer) CreateLivenessSession(ctx context.Context, opts LivenessSessionOptions) (string, error) {
+	input := &rekognition.CreateFaceLivenessSessionInput{}
+	if opts.ClientRequestToken != "" {
+		input.ClientRequestToken = aws.String(opts.ClientRequestToken)
+	}
+	if opts.KmsKeyId != "" {
+		input.KmsKeyId = aws.String(opts.KmsKeyId)
+	}
+	if opts.AuditImagesLimit > 0 || opts.OutputS3Bucket != "" {
+		input.Settings = &types.CreateFaceLivenessSessionRequestSettings{}
+		if opts.AuditImagesLimit > 0 {
+			input.Settings.AuditImagesLimit = aws.Int32(opts.AuditImagesLimit)
+		}
+		if opts.OutputS3Bucket != "" {
+			input.Settings.OutputConfig = &types.LivenessOutputConfig{S3Bucket: aws.String(opts.OutputS3Bucket)}
+			if opts.OutputS3KeyPrefix != "" {
+				input.Settings.OutputConfig.S3KeyPrefix = aws.String(opts.OutputS3KeyPrefix)
+			}
+		}
+	}
+
+	resp, err := invoke(ctx, r, "CreateFaceLivenessSession", r.client.CreateFaceLivenessSession, input)
+	if err != nil {
+		return "", fmt.Errorf("failed to create liveness session: %w", err)
+	}
+	return aws.ToString(resp.SessionId), nil
+}
+
+// GetLivenessSessionResults returns the liveness confidence (0-100) of a succeeded session and
+// its reference image. The reference image is a JPEG of the live face that can be passed
+// straight to IndexFace. It is nil when the session stores its images in S3.
+// ErrLivenessSessionNotSucceeded is returned while the session hasn't succeeded.
+func (r *rekognitionFaceIndexer) GetLivenessSessionResults(ctx context.Context, sessionId string) (float32, []byte, error) {
+	resp, err := invoke(ctx, r, "GetFaceLivenessSessionResults", r.client.GetFaceLivenessSessionResults, &rekognition.GetFaceLivenessSessionResultsInput{
+		SessionId: aws.String(sessionId),
+	})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get liveness session results: %w", err)
+	}
+	if resp.Status != types.LivenessSessionStatusSucceeded {
+		return 0, nil, fmt.Errorf("failed to get liveness session results: %w: status %s", ErrLivenessSessionNotSucceeded, resp.Status)
+	}
+
+	var referenceImage []byte
+	if resp.ReferenceImage != nil {
+		referenceImage = resp.ReferenceImage.Bytes
+	}
+	return aws.ToFloat32(resp.Confidence), referenceImage, nil
+}
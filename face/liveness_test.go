@@ -0,0 +1,55 @@
+package face
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func TestLivenessSession(t *testing.T) {
+	status := types.LivenessSessionStatusInProgress
+	fake := &fakeRekognition{
+		createLiveness: func(input *rekognition.CreateFaceLivenessSessionInput) (*rekognition.CreateFaceLivenessSessionOutput, error) {
+			if aws.ToInt32(input.Settings.AuditImagesLimit) != 2 {
+				t.Errorf("got AuditImagesLimit %v, want 2", input.Settings.AuditImagesLimit)
+			}
+			return &rekognition.CreateFaceLivenessSessionOutput{SessionId: aws.String("session-1")}, nil
+		},
+		getLivenessResults: func(input *rekognition.GetFaceLivenessSessionResultsInput) (*rekognition.GetFaceLivenessSessionResultsOutput, error) {
+			return &rekognition.GetFaceLivenessSessionResultsOutput{
+				SessionId:      input.SessionId,
+				Status:         status,
+				Confidence:     aws.Float32(97.5),
+				ReferenceImage: &types.AuditImage{Bytes: []byte("reference")},
+			}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	ctx := context.TODO()
+	sessionId, err := faceIndexer.CreateLivenessSession(ctx, LivenessSessionOptions{AuditImagesLimit: 2})
+	if err != nil {
+		t.Fatalf("error creating liveness session: %v", err)
+	}
+	if sessionId != "session-1" {
+		t.Fatalf("got session id %q, want session-1", sessionId)
+	}
+
+	if _, _, err := faceIndexer.GetLivenessSessionResults(ctx, sessionId); !errors.Is(err, ErrLivenessSessionNotSucceeded) {
+		t.Fatalf("got error %v, want %v", err, ErrLivenessSessionNotSucceeded)
+	}
+
+	status = types.LivenessSessionStatusSucceeded
+	confidence, referenceImage, err := faceIndexer.GetLivenessSessionResults(ctx, sessionId)
+	if err != nil {
+		t.Fatalf("error getting liveness results: %v", err)
+	}
+	if confidence != 97.5 || !bytes.Equal(referenceImage, []byte("reference")) {
+		t.Fatalf("got confidence %v and image %q", confidence, referenceImage)
+	}
+}
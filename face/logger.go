@@ -0,0 +1,56 @@
+package face
+
+import (
+	"context"
+	"log/slog"
+)
+
+// CorrelationIDFunc extracts the correlation ID of a request from its context,
+// returning "" when there is none.
+type CorrelationIDFunc func(ctx context.Context) string
+
+type correlationIDKey struct{}
+
+// ContextWithCorrelationID returns a copy of ctx carrying the correlation ID, which the
+// indexer adds to every log line of the calls made with that context.
+func ContextWithCorrelationID(ctx context.Context, correlationId string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, correlationId)
+}
+
+// correlationIDFromContext returns the correlation ID set by ContextWithCorrelationID
+func correlationIDFromContext(ctx context.Context) string {
+	correlationId, _ := ctx.Value(correlationIDKey{}).(string)
+	return correlationId
+}
+
+// logger returns the logger for a call, with the call's correlation ID as the
+// correlationId field when the context carries one
+func (r *rekognitionFaceIndexer) logger(ctx context.Context) *slog.Logger {
+	if r.options.quietFastMode {
+		return discardLogger
+	}
+
+	logger := r.options.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	extract := r.options.correlationID
+	if extract == nil {
+		extract = correlationIDFromContext
+	}
+	if correlationId := extract(ctx); correlationId != "" {
+		logger = logger.With("correlationId", correlationId)
+	}
+	return logger
+}
+
+// discardLogger drops every record without formatting it
+var discardLogger = slog.New(discardHandler{})
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
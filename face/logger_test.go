@@ -0,0 +1,92 @@
+package face
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+type traceKey struct{}
+
+func TestLoggerCorrelationID(t *testing.T) {
+	fake := &fakeRekognition{
+		createUser: func(*rekognition.CreateUserInput) (*rekognition.CreateUserOutput, error) {
+			return nil, &types.ConflictException{Message: aws.String("user exists")}
+		},
+	}
+
+	tests := []struct {
+		name    string
+		extract CorrelationIDFunc
+		ctx     context.Context
+		want    string
+	}{
+		{"context key", nil, ContextWithCorrelationID(context.TODO(), "req-1"), "req-1"},
+		{"extract func", func(ctx context.Context) string {
+			traceId, _ := ctx.Value(traceKey{}).(string)
+			return traceId
+		}, context.WithValue(context.TODO(), traceKey{}, "trace-1"), "trace-1"},
+		{"none", nil, context.TODO(), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			faceIndexer := &rekognitionFaceIndexer{
+				client: fake,
+				options: newOptions([]Option{
+					WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+					WithCorrelationID(tt.extract),
+				}),
+			}
+
+			if err := faceIndexer.CreateUser(tt.ctx, "event_1", "user_1"); err != nil {
+				t.Fatalf("error creating user: %v", err)
+			}
+			var line map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+				t.Fatalf("error decoding log line %q: %v", buf.String(), err)
+			}
+			if got, _ := line["correlationId"].(string); got != tt.want {
+				t.Fatalf("got correlationId %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuietFastMode(t *testing.T) {
+	var buf bytes.Buffer
+	fake := &fakeRekognition{
+		createUser: func(*rekognition.CreateUserInput) (*rekognition.CreateUserOutput, error) {
+			return nil, &types.ConflictException{Message: aws.String("user exists")}
+		},
+		searchFaces: func(*rekognition.SearchFacesInput) (*rekognition.SearchFacesOutput, error) {
+			return nil, &types.InvalidParameterException{Message: aws.String("face not found")}
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{
+		client:  fake,
+		options: newOptions([]Option{WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))), WithQuietFastMode()}),
+	}
+
+	ctx := ContextWithCorrelationID(context.TODO(), "req-1")
+	if err := faceIndexer.CreateUser(ctx, "event_1", "user_1"); err != nil {
+		t.Fatalf("error creating user: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("got log output %q, want none", buf.String())
+	}
+
+	// The selfie is searched once, without waiting for it to become searchable
+	if _, _, err := faceIndexer.SearchAndIndexSelfieFace(ctx, testJPEG(t, 100, 100), "event_1"); err == nil {
+		t.Fatal("got no error searching a face that isn't searchable")
+	}
+	if got := fake.count("SearchFaces"); got != 1 {
+		t.Fatalf("got %d SearchFaces calls, want 1", got)
+	}
+}
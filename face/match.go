@@ -0,0 +1,204 @@
+package face
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+	"github.com/samber/lo"
+)
+
+// FaceMatchResult is a single stored face matched by a search.
+type FaceMatchResult struct {
+	FaceId          string  `json:"faceId"`
+	ExternalImageId string  `json:"externalImageId"`
+	Similarity      float32 `json:"similarity"`
+	// Confidence (0-100) is how confident Rekognition was that the stored face is a face when it was indexed
+	Confidence float32 `json:"confidence"`
+}
+
+// Aggregation is the rule used to score a photo matched through several of its faces.
+type Aggregation int
+
+const (
+	// AggregateMax scores a photo by its best matching face.
+	AggregateMax Aggregation = iota
+	// AggregateMean scores a photo by the mean similarity of its matching faces.
+	AggregateMean
+	// AggregateCount scores a photo by how many of its faces matched.
+	AggregateCount
+)
+
+// PhotoMatch is the aggregated match of one stored photo (ExternalImageId).
+type PhotoMatch struct {
+	ExternalImageId string `json:"externalImageId"`
+	// Score is the aggregated similarity, or the number of matching faces for AggregateCount.
+	Score float32 `json:"score"`
+	// FaceCount is how many of the photo's faces matched.
+	FaceCount int `json:"faceCount"`
+}
+
+// AggregateMatches groups matches by normalized ExternalImageId, scores every photo with the
+// aggregation rule and returns them ranked by score, highest first.
+func AggregateMatches(matches []FaceMatchResult, aggregation Aggregation) []PhotoMatch {
+	var photos []PhotoMatch
+	totals := make(map[string]float32)
+	index := make(map[string]int)
+	for _, match := range matches {
+		externalImageId := NormalizeExternalImageId(match.ExternalImageId)
+		i, ok := index[externalImageId]
+		if !ok {
+			i = len(photos)
+			index[externalImageId] = i
+			photos = append(photos, PhotoMatch{ExternalImageId: externalImageId})
+		}
+		photo := &photos[i]
+		photo.FaceCount++
+		totals[externalImageId] += match.Similarity
+		if match.Similarity > photo.Score {
+			photo.Score = match.Similarity
+		}
+	}
+
+	for i := range photos {
+		switch aggregation {
+		case AggregateMean:
+			photos[i].Score = totals[photos[i].ExternalImageId] / float32(photos[i].FaceCount)
+		case AggregateCount:
+			photos[i].Score = float32(photos[i].FaceCount)
+		}
+	}
+	sort.SliceStable(photos, func(i, j int) bool {
+		return photos[i].Score > photos[j].Score
+	})
+	return photos
+}
+
+// Tier is a named band of similarity, e.g. definite or possible matches.
+type Tier string
+
+const (
+	// TierHigh is a definite match in DefaultTiers.
+	TierHigh Tier = "high"
+	// TierMedium is a possible match in DefaultTiers.
+	TierMedium Tier = "medium"
+)
+
+// TierBoundary is the lowest similarity (0-100) of a tier.
+type TierBoundary struct {
+	Tier          Tier
+	MinSimilarity float32
+}
+
+// DefaultTiers puts matches of 95 and above in TierHigh and from 80 up to 95 in TierMedium.
+var DefaultTiers = []TierBoundary{
+	{Tier: TierHigh, MinSimilarity: 95},
+	{Tier: TierMedium, MinSimilarity: 80},
+}
+
+// GroupMatchesByTier buckets matches into the highest tier whose MinSimilarity they reach,
+// DefaultTiers when tiers is empty, keeping their order within a tier. Matches below every
+// tier are left out.
+func GroupMatchesByTier(matches []FaceMatchResult, tiers []TierBoundary) map[Tier][]FaceMatchResult {
+	if len(tiers) == 0 {
+		tiers = DefaultTiers
+	}
+	tiers = slices.Clone(tiers)
+	sort.SliceStable(tiers, func(i, j int) bool {
+		return tiers[i].MinSimilarity > tiers[j].MinSimilarity
+	})
+
+	grouped := make(map[Tier][]FaceMatchResult)
+	for _, match := range matches {
+		tier, ok := lo.Find(tiers, func(tier TierBoundary) bool {
+			return match.Similarity >= tier.MinSimilarity
+		})
+		if ok {
+			grouped[tier.Tier] = append(grouped[tier.Tier], match)
+		}
+	}
+	return grouped
+}
+
+// keepMatch reports whether the match passes the call's similarity filter.
+// A match without a Similarity only passes when no minimum is set.
+func keepMatch(match types.FaceMatch, o callOptions) bool {
+	if match.Face == nil {
+		return false
+	}
+	if match.Similarity == nil {
+		return o.minSimilarity <= 0
+	}
+	return *match.Similarity >= o.minSimilarity
+}
+
+// matchedExternalImageIds collects the unique, normalized ExternalImageIds of the
+// matches that pass the call's similarity filter, ignoring case with WithCaseInsensitiveDedup
+func matchedExternalImageIds(matches []types.FaceMatch, o callOptions) []string {
+	// Use a slice to store ExternalImageIds
+	var externalImageIds []string
+	for _, match := range matches {
+		if keepMatch(match, o) && match.Face.ExternalImageId != nil {
+			externalImageIds = append(externalImageIds, NormalizeExternalImageId(*match.Face.ExternalImageId))
+		}
+	}
+
+	if o.caseInsensitiveDedup {
+		return lo.UniqBy(externalImageIds, strings.ToLower)
+	}
+
+	// Use lo.Uniq to filter out duplicate ExternalImageIds
+	return lo.Uniq(externalImageIds)
+}
+
+// faceMatchResults converts the matches that pass the call's similarity filter,
+// keeping one entry per FaceId
+func faceMatchResults(matches []types.FaceMatch, o callOptions) []FaceMatchResult {
+	var results []FaceMatchResult
+	for _, match := range matches {
+		if !keepMatch(match, o) {
+			continue
+		}
+		results = append(results, FaceMatchResult{
+			FaceId:          aws.ToString(match.Face.FaceId),
+			ExternalImageId: NormalizeExternalImageId(aws.ToString(match.Face.ExternalImageId)),
+			Similarity:      aws.ToFloat32(match.Similarity),
+			Confidence:      aws.ToFloat32(match.Face.Confidence),
+		})
+	}
+
+	return lo.UniqBy(results, func(result FaceMatchResult) string {
+		return result.FaceId
+	})
+}
+
+// bestMatchPerPhoto keeps the match with the highest similarity of each ExternalImageId, ordered
+// by similarity, highest first
+func bestMatchPerPhoto(matches []FaceMatchResult) []FaceMatchResult {
+	best := make(map[string]int)
+	var results []FaceMatchResult
+	for _, match := range matches {
+		i, ok := best[match.ExternalImageId]
+		if !ok {
+			best[match.ExternalImageId] = len(results)
+			results = append(results, match)
+		} else if match.Similarity > results[i].Similarity {
+			results[i] = match
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Similarity > results[j].Similarity
+	})
+	return results
+}
+
+// noMatchError returns ErrNoMatch when a search found no match and the call asked for it with
+// WithNoMatchError, and nil otherwise
+func noMatchError(matches int, o callOptions) error {
+	if o.noMatchError && matches == 0 {
+		return ErrNoMatch
+	}
+	return nil
+}
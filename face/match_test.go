@@ -0,0 +1,101 @@
+package face
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func TestAggregateMatches(t *testing.T) {
+	matches := []FaceMatchResult{
+		{FaceId: "face-1", ExternalImageId: "photo_1", Similarity: 90},
+		{FaceId: "face-2", ExternalImageId: "photo_2", Similarity: 99},
+		{FaceId: "face-3", ExternalImageId: "photo_1", Similarity: 80},
+		{FaceId: "face-4", ExternalImageId: "photo_1", Similarity: 85},
+	}
+
+	tests := []struct {
+		name        string
+		aggregation Aggregation
+		want        []PhotoMatch
+	}{
+		{name: "max", aggregation: AggregateMax, want: []PhotoMatch{
+			{ExternalImageId: "photo_2", Score: 99, FaceCount: 1},
+			{ExternalImageId: "photo_1", Score: 90, FaceCount: 3},
+		}},
+		{name: "mean", aggregation: AggregateMean, want: []PhotoMatch{
+			{ExternalImageId: "photo_2", Score: 99, FaceCount: 1},
+			{ExternalImageId: "photo_1", Score: 85, FaceCount: 3},
+		}},
+		{name: "count", aggregation: AggregateCount, want: []PhotoMatch{
+			{ExternalImageId: "photo_1", Score: 3, FaceCount: 3},
+			{ExternalImageId: "photo_2", Score: 1, FaceCount: 1},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AggregateMatches(matches, tt.aggregation); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupMatchesByTier(t *testing.T) {
+	matches := []FaceMatchResult{
+		{FaceId: "face-1", ExternalImageId: "photo_1", Similarity: 99},
+		{FaceId: "face-2", ExternalImageId: "photo_2", Similarity: 95},
+		{FaceId: "face-3", ExternalImageId: "photo_3", Similarity: 90},
+		{FaceId: "face-4", ExternalImageId: "photo_4", Similarity: 70},
+	}
+
+	got := GroupMatchesByTier(matches, nil)
+	want := map[Tier][]FaceMatchResult{
+		TierHigh:   {matches[0], matches[1]},
+		TierMedium: {matches[2]},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	// Custom boundaries, in any order
+	got = GroupMatchesByTier(matches, []TierBoundary{{Tier: "possible", MinSimilarity: 60}, {Tier: "definite", MinSimilarity: 98}})
+	want = map[Tier][]FaceMatchResult{
+		"definite": {matches[0]},
+		"possible": {matches[1], matches[2], matches[3]},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestMatchedExternalImageIdsCaseInsensitiveDedup(t *testing.T) {
+	matches := []types.FaceMatch{
+		faceMatch("face-1", "Photo_123", 99),
+		faceMatch("face-2", "photo_123", 95),
+		faceMatch("face-3", "photo_456", 90),
+	}
+
+	if got, want := matchedExternalImageIds(matches, callOptions{}), []string{"Photo_123", "photo_123", "photo_456"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v by default, want %v", got, want)
+	}
+	o := newCallOptions([]CallOption{WithCaseInsensitiveDedup()})
+	if got, want := matchedExternalImageIds(matches, o), []string{"Photo_123", "photo_456"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v ignoring case, want %v", got, want)
+	}
+}
+
+func TestMatchedExternalImageIdsUnscoredMatch(t *testing.T) {
+	unscored := faceMatch("face-2", "photo_456", 0)
+	unscored.Similarity = nil
+	matches := []types.FaceMatch{faceMatch("face-1", "photo_123", 95), unscored}
+
+	if got, want := matchedExternalImageIds(matches, callOptions{}), []string{"photo_123", "photo_456"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v without a minimum, want %v", got, want)
+	}
+	o := newCallOptions([]CallOption{WithMinSimilarity(90)})
+	if got, want := matchedExternalImageIds(matches, o), []string{"photo_123"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v with a minimum, want %v", got, want)
+	}
+}
@@ -0,0 +1,56 @@
+package face
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// Metrics receives per-operation retry counters from the indexer, so dashboards can tell
+// latency caused by cold collections apart from latency caused by throttling. Methods are
+// only called when the count is positive and may be called concurrently.
+type Metrics interface {
+	// ConsistencyWaits reports how many times a search of a just-indexed face was retried
+	// because Rekognition didn't know the face yet
+	ConsistencyWaits(operation string, waits int)
+	// ThrottleRetries reports how many times the AWS SDK retried an operation after a
+	// throttling error
+	ThrottleRetries(operation string, retries int)
+}
+
+// recordConsistencyWaits reports the extra attempts a consistency wait took
+func (r *rekognitionFaceIndexer) recordConsistencyWaits(operation string, attempts int) {
+	if r.options.metrics != nil && attempts > 1 {
+		r.options.metrics.ConsistencyWaits(operation, attempts-1)
+	}
+}
+
+// throttleRetryMetrics counts the SDK's attempts at operation that were retried after a
+// throttling error and reports them to metrics. The SDK records its attempts in the
+// response metadata, which is read on the way out of the initialize step.
+func throttleRetryMetrics(metrics Metrics, operation string) func(*rekognition.Options) {
+	throttles := retry.IsErrorThrottles(retry.DefaultThrottles)
+	count := middleware.InitializeMiddlewareFunc("ThrottleRetryMetrics", func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+		out, metadata, err := next.HandleInitialize(ctx, in)
+		if results, ok := retry.GetAttemptResults(metadata); ok {
+			retries := 0
+			for _, attempt := range results.Results {
+				if attempt.Retried && throttles.IsErrorThrottle(attempt.Err) == aws.TrueTernary {
+					retries++
+				}
+			}
+			if retries > 0 {
+				metrics.ThrottleRetries(operation, retries)
+			}
+		}
+		return out, metadata, err
+	})
+	return func(o *rekognition.Options) {
+		o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+			return stack.Initialize.Add(count, middleware.After)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package face
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+// recordingMetrics sums the counters reported to it by operation
+type recordingMetrics struct {
+	mu               sync.Mutex
+	consistencyWaits map[string]int
+	throttleRetries  map[string]int
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{consistencyWaits: map[string]int{}, throttleRetries: map[string]int{}}
+}
+
+func (m *recordingMetrics) ConsistencyWaits(operation string, waits int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.consistencyWaits[operation] += waits
+}
+
+func (m *recordingMetrics) ThrottleRetries(operation string, retries int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.throttleRetries[operation] += retries
+}
+
+func TestConsistencyWaitMetrics(t *testing.T) {
+	fake := &fakeRekognition{}
+	fake.searchFaces = func(*rekognition.SearchFacesInput) (*rekognition.SearchFacesOutput, error) {
+		if fake.count("SearchFaces") < 3 {
+			return nil, &types.InvalidParameterException{Message: aws.String("face not found")}
+		}
+		return &rekognition.SearchFacesOutput{}, nil
+	}
+	metrics := newRecordingMetrics()
+	faceIndexer := &rekognitionFaceIndexer{client: fake, options: newOptions([]Option{WithMetrics(metrics)})}
+
+	if _, err := faceIndexer.waitForFaceSearchable(context.TODO(), "event_1", "selfie-face", time.Second, callOptions{}); err != nil {
+		t.Fatalf("error waiting for face: %v", err)
+	}
+	if metrics.consistencyWaits["SearchFaces"] != 2 || len(metrics.throttleRetries) != 0 {
+		t.Fatalf("got consistency waits %v and throttle retries %v, want 2 SearchFaces waits only", metrics.consistencyWaits, metrics.throttleRetries)
+	}
+}
+
+func TestThrottleRetryMetrics(t *testing.T) {
+	// Throttle the first two DescribeCollection attempts, as Rekognition would
+	var mu sync.Mutex
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		attempt := attempts
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		if attempt <= 2 {
+			w.Header().Set("X-Amzn-ErrorType", "ThrottlingException")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"ThrottlingException","message":"Rate exceeded"}`))
+			return
+		}
+		w.Write([]byte(`{"FaceCount":3}`))
+	}))
+	defer server.Close()
+
+	client := rekognition.New(rekognition.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("key", "secret", ""),
+		Retryer: retry.NewStandard(func(o *retry.StandardOptions) {
+			o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+			o.RateLimiter = ratelimit.None
+		}),
+	})
+	metrics := newRecordingMetrics()
+	faceIndexer := NewRekognitionFaceIndexer(client, WithMetrics(metrics))
+
+	if _, err := faceIndexer.DescribeCollection(context.TODO(), "event_1"); err != nil {
+		t.Fatalf("error describing collection: %v", err)
+	}
+	if metrics.throttleRetries["DescribeCollection"] != 2 || len(metrics.consistencyWaits) != 0 {
+		t.Fatalf("got throttle retries %v and consistency waits %v, want 2 DescribeCollection retries only", metrics.throttleRetries, metrics.consistencyWaits)
+	}
+}
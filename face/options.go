@@ -0,0 +1,459 @@
+package face
+
+import (
+	"image/color"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+	"golang.org/x/time/rate"
+)
+
+// Option configures how the indexer is constructed.
+type Option func(*options)
+
+type options struct {
+	retryMaxAttempts         int
+	retryMode                aws.RetryMode
+	externalImageIdGenerator ExternalImageIdGenerator
+	defaultOperationTimeout  time.Duration
+	faceSearchableMaxWait    time.Duration
+	logger                   *slog.Logger
+	correlationID            CorrelationIDFunc
+	quietFastMode            bool
+	httpClient               *http.Client
+	downloadTimeout          time.Duration
+	limiter                  *rate.Limiter
+	autoOrientOnUpload       bool
+	cropUploader             cropUploader
+	metrics                  Metrics
+	maxDimension             int
+	faceModelVersion         string
+	s3SizeCheck              S3HeadObjectAPI
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// ExternalImageIdGenerator returns the ExternalImageId used when the indexer has to
+// make one up, such as for the selfie indexed by SearchAndIndexSelfieFace. The
+// result must only contain characters Rekognition allows: [a-zA-Z0-9_.\-:].
+type ExternalImageIdGenerator func(collectionId string) string
+
+// WithExternalImageIdGenerator replaces the default "<uuid>_<collectionId>"
+// format of the synthetic ExternalImageIds.
+func WithExternalImageIdGenerator(generator ExternalImageIdGenerator) Option {
+	return func(o *options) {
+		o.externalImageIdGenerator = generator
+	}
+}
+
+// WithDefaultOperationTimeout bounds every AWS call by timeout when the
+// caller's context has no deadline, as a safety net against calls that hang
+// on network issues. A deadline set by the caller always takes precedence.
+func WithDefaultOperationTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.defaultOperationTimeout = timeout
+	}
+}
+
+// WithFaceSearchableMaxWait bounds how long SearchAndIndexSelfieFace and
+// SearchFacebyFaceId keep retrying, with growing delays, while the face
+// they search isn't searchable yet. It defaults to 5 seconds.
+func WithFaceSearchableMaxWait(maxWait time.Duration) Option {
+	return func(o *options) {
+		o.faceSearchableMaxWait = maxWait
+	}
+}
+
+// WithLogger sends the indexer's log lines to logger instead of slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithCorrelationID adds the ID extract pulls out of a call's context to every
+// log line of that call, as the correlationId field, so Rekognition calls can be
+// traced back to the user request. Without it the ID set by
+// ContextWithCorrelationID is used.
+func WithCorrelationID(extract CorrelationIDFunc) Option {
+	return func(o *options) {
+		o.correlationID = extract
+	}
+}
+
+// WithQuietFastMode turns off all logging and every artificial delay, for
+// benchmarking the API-bound throughput. It skips the eventual-consistency
+// wait of SearchAndIndexSelfieFace and SearchFacebyFaceId too, so a face
+// searched right after being indexed may not be found yet; only opt in
+// knowingly.
+func WithQuietFastMode() Option {
+	return func(o *options) {
+		o.quietFastMode = true
+	}
+}
+
+// WithHTTPClient sets the client IndexFaceFromURL downloads images with,
+// http.DefaultClient by default.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithDownloadTimeout bounds each image download of IndexFaceFromURL. It
+// defaults to 30 seconds.
+func WithDownloadTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.downloadTimeout = timeout
+	}
+}
+
+// WithRateLimit caps the indexer at requestsPerSecond AWS calls, allowing
+// bursts of up to burst calls, to stay under the account's TPS limits during
+// bulk jobs. The limit is shared by every operation of the indexer and each
+// call waits for its turn, bounded by the caller's context.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(o *options) {
+		o.limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), max(burst, 1))
+	}
+}
+
+// WithAutoOrientOnUpload rotates images with an EXIF orientation upright
+// before they are sent to IndexFaces and SearchFacesByImage, so Rekognition
+// only ever sees upright pixels. It costs a decode and re-encode of rotated
+// images; images in S3 are sent as they are.
+func WithAutoOrientOnUpload() Option {
+	return func(o *options) {
+		o.autoOrientOnUpload = true
+	}
+}
+
+// WithMaxDimension downscales images whose longest side is over maxDimension
+// pixels, keeping their aspect ratio, before they are sent to IndexFaces and
+// SearchFacesByImage, saving bandwidth on very large photos. Bounding boxes are
+// normalized, so they still map onto the original image. Off by default.
+func WithMaxDimension(maxDimension int) Option {
+	return func(o *options) {
+		o.maxDimension = maxDimension
+	}
+}
+
+// WithCropUploader makes SearchAndIndexSelfieFaceWithCrop store the selfie's
+// crop in bucket, under the key returned by key, and return its S3 URI next to
+// the bytes. client is typically an *s3.Client.
+func WithCropUploader(client S3PutObjectAPI, bucket string, key CropKeyFunc) Option {
+	return func(o *options) {
+		o.cropUploader = cropUploader{client: client, bucket: bucket, key: key}
+	}
+}
+
+// WithS3SizeCheck makes the methods reading images from S3 check the object's
+// size with HeadObject first, and fail with ErrImageTooLarge and the actual size
+// when it is over the 15MB Rekognition accepts, instead of an opaque Rekognition
+// error. client is typically an *s3.Client. Without it no check is made.
+func WithS3SizeCheck(client S3HeadObjectAPI) Option {
+	return func(o *options) {
+		o.s3SizeCheck = client
+	}
+}
+
+// WithMetrics reports, per operation, the retries spent waiting for
+// just-indexed faces to become searchable and the retries the AWS SDK made
+// after throttling errors, as separate counters.
+func WithMetrics(metrics Metrics) Option {
+	return func(o *options) {
+		o.metrics = metrics
+	}
+}
+
+// WithRetryMaxAttempts sets the maximum number of attempts the AWS SDK
+// retryer makes per Rekognition call. It applies when the indexer builds its
+// own client with NewFromEnv.
+func WithRetryMaxAttempts(maxAttempts int) Option {
+	return func(o *options) {
+		o.retryMaxAttempts = maxAttempts
+	}
+}
+
+// WithRetryMode selects the AWS SDK retryer mode, aws.RetryModeStandard or
+// aws.RetryModeAdaptive. It applies when the indexer builds its own client
+// with NewFromEnv.
+func WithRetryMode(mode aws.RetryMode) Option {
+	return func(o *options) {
+		o.retryMode = mode
+	}
+}
+
+// WithFaceModelVersion pins the face model version, e.g. "7.0", collections must use. Rekognition
+// creates collections on its current default model, so a collection on another version fails to
+// be ensured with ErrFaceModelVersionMismatch instead of enrolling faces with different behavior.
+func WithFaceModelVersion(version string) Option {
+	return func(o *options) {
+		o.faceModelVersion = version
+	}
+}
+
+// CallOption configures a single call on the Face interface.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	minSimilarity           float32
+	includeSearchedFace     bool
+	detectionAttributes     []types.Attribute
+	outputFormat            ImageFormat
+	minDetectionConfidence  float32
+	maxFaces                int32
+	faceMatchThreshold      float32
+	apiOptions              []func(*rekognition.Options)
+	idempotent              bool
+	grayscale               bool
+	normalizeContrast       bool
+	faceSelector            FaceSelector
+	ignoreMissingCollection bool
+	lowQualityConfidence    float32
+	originalImage           bool
+	cropBackground          color.Color
+	faceRecords             bool
+	progress                func(done int, total int)
+	caseInsensitiveDedup    bool
+	seenContent             func(hash string) bool
+	errorPolicy             ErrorPolicy
+	noMatchError            bool
+	// searchOnly searches a selfie with SearchFacesByImage instead of indexing it, see SearchSelfieFaceWithCrop
+	searchOnly bool
+}
+
+func newCallOptions(opts []CallOption) callOptions {
+	var o callOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithMinSimilarity drops matches whose Similarity is below minSimilarity,
+// or missing, from the returned set. The filter is applied on top of the matches
+// Rekognition returns, so a single collection can serve both lenient and
+// strict callers without re-querying.
+func WithMinSimilarity(minSimilarity float32) CallOption {
+	return func(o *callOptions) {
+		o.minSimilarity = minSimilarity
+	}
+}
+
+// WithIncludeSearchedFace keeps the ExternalImageId generated for the selfie
+// in the results of SearchAndIndexSelfieFace. By default it is filtered out,
+// since the synthetic enrollment id isn't a matched photo.
+func WithIncludeSearchedFace() CallOption {
+	return func(o *callOptions) {
+		o.includeSearchedFace = true
+	}
+}
+
+// WithDetectionAttributes sets the facial attributes Rekognition returns for
+// this call, e.g. types.AttributeAll for a high-value enrollment. Requesting
+// ALL costs more and is slower, so it is scoped to the request rather than
+// the indexer; without it Rekognition returns the DEFAULT set.
+func WithDetectionAttributes(attributes ...types.Attribute) CallOption {
+	return func(o *callOptions) {
+		o.detectionAttributes = attributes
+	}
+}
+
+// WithOutputFormat encodes crops in format instead of following the source
+// image, which keeps PNG sources (and their transparency) as PNG and
+// encodes everything else as JPEG.
+func WithOutputFormat(format ImageFormat) CallOption {
+	return func(o *callOptions) {
+		o.outputFormat = format
+	}
+}
+
+// WithMinDetectionConfidence keeps only the faces Rekognition is at least
+// minConfidence (0-100) sure are faces, to avoid enrolling artifacts.
+// IndexFaces can't be told to skip them, so faces below it are deleted right
+// after indexing and reported as skipped with ReasonLowDetectionConfidence.
+func WithMinDetectionConfidence(minConfidence float32) CallOption {
+	return func(o *callOptions) {
+		o.minDetectionConfidence = minConfidence
+	}
+}
+
+// WithMaxFaces asks SearchFaces for up to maxFaces matches, e.g. 500 to find
+// every other photo of an enrolled face. Rekognition returns at most 4096
+// matches and doesn't paginate SearchFaces, so larger values are capped.
+func WithMaxFaces(maxFaces int32) CallOption {
+	return func(o *callOptions) {
+		o.maxFaces = maxFaces
+	}
+}
+
+// WithFaceMatchThreshold sets the FaceMatchThreshold (0-100) of SearchFaces.
+// Unlike WithMinSimilarity it is applied by Rekognition, so weaker matches
+// don't use up the MaxFaces budget.
+func WithFaceMatchThreshold(threshold float32) CallOption {
+	return func(o *callOptions) {
+		o.faceMatchThreshold = threshold
+	}
+}
+
+// WithAPIOptions forwards optFns to every AWS call the method makes, e.g. to
+// add headers for a proxy or swap the HTTP client for a single call, without
+// rebuilding the client.
+func WithAPIOptions(optFns ...func(*rekognition.Options)) CallOption {
+	return func(o *callOptions) {
+		o.apiOptions = append(o.apiOptions, optFns...)
+	}
+}
+
+// WithIdempotentIndex makes indexing idempotent per ExternalImageId: when the
+// collection already has a face with the ExternalImageId, nothing is indexed
+// and the stored faces are returned instead, so a retried upload doesn't
+// enroll duplicates. The check lists the whole collection before every image,
+// one ListFaces call per 4096 faces, so its cost grows with the collection; see
+// EstimateBatchOperations. Two concurrent calls for the same ExternalImageId can
+// still both index.
+func WithIdempotentIndex() CallOption {
+	return func(o *callOptions) {
+		o.idempotent = true
+	}
+}
+
+// WithGrayscale converts crops to grayscale before they are encoded.
+func WithGrayscale() CallOption {
+	return func(o *callOptions) {
+		o.grayscale = true
+	}
+}
+
+// WithNormalizeContrast stretches the histogram of crops over the full
+// brightness range before they are encoded, evening out dark or washed-out
+// faces for downstream models.
+func WithNormalizeContrast() CallOption {
+	return func(o *callOptions) {
+		o.normalizeContrast = true
+	}
+}
+
+// WithFaceSelector makes SearchSelfieFace detect every face and search the one
+// selector picks, e.g. MostCentralFace or HighestConfidenceFace, instead of
+// letting Rekognition search the largest. It costs an extra DetectFaces call.
+func WithFaceSelector(selector FaceSelector) CallOption {
+	return func(o *callOptions) {
+		o.faceSelector = selector
+	}
+}
+
+// WithIgnoreMissingCollection makes DeleteFacebyFaceIds succeed when the
+// collection doesn't exist, with nothing deleted and no failed faces, so
+// idempotent cleanup jobs can rerun after the collection is gone.
+func WithIgnoreMissingCollection() CallOption {
+	return func(o *callOptions) {
+		o.ignoreMissingCollection = true
+	}
+}
+
+// WithLowQualityConfidence sets the SearchedFaceConfidence (0-100) below which
+// a search reports LowQualityInput, e.g. to warn that a blurry selfie may give
+// unreliable matches. It defaults to DefaultLowQualityConfidence.
+func WithLowQualityConfidence(minConfidence float32) CallOption {
+	return func(o *callOptions) {
+		o.lowQualityConfidence = minConfidence
+	}
+}
+
+// WithOriginalImage makes SearchAndIndexSelfieFaceWithCrop return the selfie
+// bytes it was given, untouched, next to the corrected crop, so audit logs can
+// keep what was sent alongside what was displayed. The bytes aren't copied.
+func WithOriginalImage() CallOption {
+	return func(o *callOptions) {
+		o.originalImage = true
+	}
+}
+
+// WithCropBackground sets the color transparent areas of PNG sources are filled
+// with when a crop is encoded as a JPEG, which has no transparency. It defaults
+// to white; PNG crops keep their transparency.
+func WithCropBackground(background color.Color) CallOption {
+	return func(o *callOptions) {
+		o.cropBackground = background
+	}
+}
+
+// WithFaceRecords makes IndexFaceDetailed return each face's Face and
+// FaceDetail as IndexFaces returned them, so the analysis requested with
+// WithDetectionAttributes is available without another call.
+func WithFaceRecords() CallOption {
+	return func(o *callOptions) {
+		o.faceRecords = true
+	}
+}
+
+// WithProgress makes CoverageReport call progress after every face it
+// searched, with the number of faces done and the total. Calls don't overlap.
+func WithProgress(progress func(done int, total int)) CallOption {
+	return func(o *callOptions) {
+		o.progress = progress
+	}
+}
+
+// WithCaseInsensitiveDedup makes searches that return ExternalImageIds treat ids
+// differing only in case, e.g. "Photo_123" and "photo_123", as the same photo,
+// keeping the first one matched. By default ids are deduplicated exactly.
+func WithCaseInsensitiveDedup() CallOption {
+	return func(o *callOptions) {
+		o.caseInsensitiveDedup = true
+	}
+}
+
+// WithSeenContent makes indexing from image bytes call seen with the ContentHash of
+// the image first and, when it reports the content as already indexed, skip the
+// image with ErrAlreadyIndexed without any call. The indexer keeps no hashes itself,
+// callers dedupe against their own store. In batches the skip is reported per item.
+func WithSeenContent(seen func(hash string) bool) CallOption {
+	return func(o *callOptions) {
+		o.seenContent = seen
+	}
+}
+
+// WithErrorPolicy sets what IndexFacesBatch and IndexDirectory do when an image
+// fails to index. It defaults to ContinueOnError; FailFast suits strict imports
+// that must be all or nothing.
+func WithErrorPolicy(policy ErrorPolicy) CallOption {
+	return func(o *callOptions) {
+		o.errorPolicy = policy
+	}
+}
+
+// WithNoMatchError makes every Search method fail with ErrNoMatch when it found no
+// match at all, instead of returning an empty result and no error. Methods searching
+// several faces or collections (SearchGroupPhoto, SearchManyByFaceIds and
+// SearchFaceAcrossCollections) only fail when none of them matched. The result is
+// still returned next to the error, e.g. the selfie's FaceId or crop. Raw searches
+// and reports such as CoverageReport and FindDuplicateFaces ignore it.
+func WithNoMatchError() CallOption {
+	return func(o *callOptions) {
+		o.noMatchError = true
+	}
+}
+
+// cropFormat is the format crops of a sourceFormat image are encoded in
+func (o callOptions) cropFormat(sourceFormat ImageFormat) ImageFormat {
+	if o.outputFormat != "" {
+		return o.outputFormat
+	}
+	if sourceFormat == FormatPNG {
+		return FormatPNG
+	}
+	return FormatJPEG
+}
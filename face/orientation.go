@@ -0,0 +1,173 @@
+package face
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+// exifOrientation values, as defined by the EXIF spec
+const (
+	orientationNormal     = 1
+	orientationFlipH      = 2
+	orientationRotate180  = 3
+	orientationFlipV      = 4
+	orientationTranspose  = 5
+	orientationRotate90   = 6
+	orientationTransverse = 7
+	orientationRotate270  = 8
+)
+
+// readExifOrientation returns the EXIF orientation of JPEG bytes, or
+// orientationNormal when the image has none (PNGs never do).
+func readExifOrientation(imageBytes []byte) int {
+	if len(imageBytes) < 4 || imageBytes[0] != 0xFF || imageBytes[1] != 0xD8 {
+		return orientationNormal
+	}
+
+	// Walk the JPEG segments until the APP1 Exif segment or the image data
+	for offset := 2; offset+4 <= len(imageBytes); {
+		if imageBytes[offset] != 0xFF {
+			return orientationNormal
+		}
+		marker := imageBytes[offset+1]
+		if marker == 0xDA || marker == 0xD9 {
+			return orientationNormal
+		}
+		length := int(binary.BigEndian.Uint16(imageBytes[offset+2:]))
+		end := offset + 2 + length
+		if length < 2 || end > len(imageBytes) {
+			return orientationNormal
+		}
+		segment := imageBytes[offset+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		offset = end
+	}
+	return orientationNormal
+}
+
+// tiffOrientation reads the Orientation tag from IFD0 of a TIFF header
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return orientationNormal
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return orientationNormal
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return orientationNormal
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return orientationNormal
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < orientationNormal || orientation > orientationRotate270 {
+				return orientationNormal
+			}
+			return orientation
+		}
+	}
+	return orientationNormal
+}
+
+// applyOrientation turns img upright according to its EXIF orientation
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation == orientationNormal {
+		return img
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	outWidth, outHeight := width, height
+	if orientation >= orientationTranspose {
+		outWidth, outHeight = height, width
+	}
+
+	// source maps a pixel of the upright image back to the stored image
+	source := func(x, y int) (int, int) {
+		switch orientation {
+		case orientationFlipH:
+			return width - 1 - x, y
+		case orientationRotate180:
+			return width - 1 - x, height - 1 - y
+		case orientationFlipV:
+			return x, height - 1 - y
+		case orientationTranspose:
+			return y, x
+		case orientationRotate90:
+			return y, height - 1 - x
+		case orientationTransverse:
+			return width - 1 - y, height - 1 - x
+		default:
+			return width - 1 - y, x
+		}
+	}
+
+	src := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+	upright := image.NewRGBA(image.Rect(0, 0, outWidth, outHeight))
+	for y := 0; y < outHeight; y++ {
+		for x := 0; x < outWidth; x++ {
+			sx, sy := source(x, y)
+			upright.SetRGBA(x, y, src.RGBAAt(sx, sy))
+		}
+	}
+	return upright
+}
+
+// decodeUpright decodes the image and applies its EXIF orientation, so it
+// matches the upright image Rekognition's bounding boxes refer to.
+func decodeUpright(imageBytes []byte) (image.Image, ImageFormat, error) {
+	img, format, err := decodeImage(imageBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	return applyOrientation(img, readExifOrientation(imageBytes)), format, nil
+}
+
+// uploadImage returns the image to send to Rekognition. With WithAutoOrientOnUpload, image
+// bytes carrying an EXIF orientation are rotated upright, and with WithMaxDimension, images
+// larger than the limit are downscaled; either is re-encoded upright in its format. S3 images
+// and images needing neither are sent as they are.
+func (r *rekognitionFaceIndexer) uploadImage(image *types.Image) (*types.Image, error) {
+	if image.Bytes == nil {
+		return image, nil
+	}
+	rotate := r.options.autoOrientOnUpload && readExifOrientation(image.Bytes) != orientationNormal
+	shrink := r.options.maxDimension > 0 && exceedsDimension(image.Bytes, r.options.maxDimension)
+	if !rotate && !shrink {
+		return image, nil
+	}
+
+	// Re-encoding drops the EXIF orientation, so the pixels are always made upright
+	img, format, err := decodeUpright(image.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare image: %w", err)
+	}
+	if shrink {
+		img = fitWithin(img, r.options.maxDimension)
+	}
+	prepared, err := encodeImage(img, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare image: %w", err)
+	}
+	return &types.Image{Bytes: prepared}, nil
+}
@@ -0,0 +1,161 @@
+package face
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+// withExifOrientation inserts an APP1 Exif segment holding only the
+// Orientation tag right after the SOI marker of jpegBytes
+func withExifOrientation(jpegBytes []byte, orientation uint16) []byte {
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	out := append([]byte{}, jpegBytes[:2]...)
+	out = append(out, app1...)
+	return append(out, jpegBytes[2:]...)
+}
+
+func TestReadExifOrientation(t *testing.T) {
+	plain := testJPEG(t, 100, 100)
+	if got := readExifOrientation(plain); got != orientationNormal {
+		t.Fatalf("got orientation %d for a jpeg without exif, want %d", got, orientationNormal)
+	}
+	if got := readExifOrientation(withExifOrientation(plain, orientationRotate90)); got != orientationRotate90 {
+		t.Fatalf("got orientation %d, want %d", got, orientationRotate90)
+	}
+	if got := readExifOrientation(testPNG(t, 100, 100)); got != orientationNormal {
+		t.Fatalf("got orientation %d for a png, want %d", got, orientationNormal)
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// A 3x2 image with a marked top-left pixel
+	img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	marker := color.RGBA{R: 255, A: 255}
+	img.SetRGBA(0, 0, marker)
+
+	tests := []struct {
+		orientation int
+		wantSize    image.Point
+		wantMarker  image.Point
+	}{
+		{orientation: orientationNormal, wantSize: image.Pt(3, 2), wantMarker: image.Pt(0, 0)},
+		{orientation: orientationFlipH, wantSize: image.Pt(3, 2), wantMarker: image.Pt(2, 0)},
+		{orientation: orientationRotate180, wantSize: image.Pt(3, 2), wantMarker: image.Pt(2, 1)},
+		{orientation: orientationFlipV, wantSize: image.Pt(3, 2), wantMarker: image.Pt(0, 1)},
+		{orientation: orientationTranspose, wantSize: image.Pt(2, 3), wantMarker: image.Pt(0, 0)},
+		{orientation: orientationRotate90, wantSize: image.Pt(2, 3), wantMarker: image.Pt(1, 0)},
+		{orientation: orientationTransverse, wantSize: image.Pt(2, 3), wantMarker: image.Pt(1, 2)},
+		{orientation: orientationRotate270, wantSize: image.Pt(2, 3), wantMarker: image.Pt(0, 2)},
+	}
+	for _, tt := range tests {
+		upright := applyOrientation(img, tt.orientation)
+		if got := upright.Bounds().Size(); got != tt.wantSize {
+			t.Fatalf("orientation %d: got size %v, want %v", tt.orientation, got, tt.wantSize)
+		}
+		if got := color.RGBAModel.Convert(upright.At(tt.wantMarker.X, tt.wantMarker.Y)); got != marker {
+			t.Fatalf("orientation %d: marker not at %v", tt.orientation, tt.wantMarker)
+		}
+	}
+}
+
+func TestAutoOrientOnUpload(t *testing.T) {
+	rotated := withExifOrientation(testJPEG(t, 200, 100), orientationRotate90)
+	var uploads [][]byte
+	fake := &fakeRekognition{
+		indexFaces: func(input *rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			uploads = append(uploads, input.Image.Bytes)
+			return (&fakeRekognition{}).IndexFaces(context.TODO(), input)
+		},
+		searchFacesByImage: func(input *rekognition.SearchFacesByImageInput) (*rekognition.SearchFacesByImageOutput, error) {
+			uploads = append(uploads, input.Image.Bytes)
+			return &rekognition.SearchFacesByImageOutput{}, nil
+		},
+	}
+
+	faceIndexer := &rekognitionFaceIndexer{client: fake, options: newOptions([]Option{WithAutoOrientOnUpload()})}
+	if err := faceIndexer.IndexFace(context.TODO(), rotated, "photo_1", "event_1"); err != nil {
+		t.Fatalf("error indexing face: %v", err)
+	}
+	if _, err := faceIndexer.searchFacesByImage(context.TODO(), &types.Image{Bytes: rotated}, "event_1", 0, callOptions{}); err != nil {
+		t.Fatalf("error searching face: %v", err)
+	}
+	for _, upload := range uploads {
+		if readExifOrientation(upload) != orientationNormal {
+			t.Fatal("uploaded image still carries an exif orientation")
+		}
+		config, _, err := image.DecodeConfig(bytes.NewReader(upload))
+		if err != nil {
+			t.Fatalf("error decoding upload: %v", err)
+		}
+		if config.Width != 100 || config.Height != 200 {
+			t.Fatalf("got a %dx%d upload, want the upright 100x200", config.Width, config.Height)
+		}
+	}
+
+	// Off by default, the bytes are sent as they are
+	uploads = nil
+	faceIndexer = &rekognitionFaceIndexer{client: fake}
+	if err := faceIndexer.IndexFace(context.TODO(), rotated, "photo_1", "event_1"); err != nil {
+		t.Fatalf("error indexing face: %v", err)
+	}
+	if !bytes.Equal(uploads[0], rotated) {
+		t.Fatal("expected the image to be uploaded untouched")
+	}
+}
+
+func TestAutoOrientOnUploadSelfie(t *testing.T) {
+	rotated := withExifOrientation(testJPEG(t, 200, 100), orientationRotate90)
+	var uploads [][]byte
+	fake := &fakeRekognition{
+		indexFaces: func(input *rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			uploads = append(uploads, input.Image.Bytes)
+			bbox := boundingBox(0.25, 0.25, 0.5, 0.5)
+			return &rekognition.IndexFacesOutput{
+				FaceRecords: []types.FaceRecord{{Face: &types.Face{FaceId: aws.String("selfie-face"), BoundingBox: &bbox}}},
+			}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake, options: newOptions([]Option{WithAutoOrientOnUpload()})}
+
+	if _, _, err := faceIndexer.SearchAndIndexSelfieFace(context.TODO(), rotated, "event_1"); err != nil {
+		t.Fatalf("error searching selfie: %v", err)
+	}
+	if _, err := faceIndexer.SearchAndIndexSelfieFaceWithCrop(context.TODO(), rotated, "event_1"); err != nil {
+		t.Fatalf("error searching selfie with crop: %v", err)
+	}
+	if len(uploads) != 2 {
+		t.Fatalf("got %d IndexFaces calls, want 2", len(uploads))
+	}
+	for _, upload := range uploads {
+		if readExifOrientation(upload) != orientationNormal {
+			t.Fatal("uploaded selfie still carries an exif orientation")
+		}
+		config, _, err := image.DecodeConfig(bytes.NewReader(upload))
+		if err != nil {
+			t.Fatalf("error decoding upload: %v", err)
+		}
+		if config.Width != 100 || config.Height != 200 {
+			t.Fatalf("got a %dx%d upload, want the upright 100x200", config.Width, config.Height)
+		}
+	}
+}
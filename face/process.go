@@ -0,0 +1,114 @@
+package face
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// contrastClip is the share of darkest and brightest pixels ignored when stretching
+// the histogram, so a few outliers don't cancel the normalization
+const contrastClip = 0.01
+
+// encodeCrop applies the crop transforms requested for the call and encodes the crop
+// of a sourceFormat image
+func (o callOptions) encodeCrop(cropped image.Image, sourceFormat ImageFormat) ([]byte, error) {
+	if o.grayscale {
+		cropped = grayscale(cropped)
+	}
+	if o.normalizeContrast {
+		cropped = normalizeContrast(cropped)
+	}
+	format := o.cropFormat(sourceFormat)
+	// JPEG has no transparency, so fill transparent areas instead of letting them turn black
+	if format == FormatJPEG {
+		background := o.cropBackground
+		if background == nil {
+			background = color.White
+		}
+		cropped = flatten(cropped, background)
+	}
+	return encodeImage(cropped, format)
+}
+
+// flatten composites img over a solid background, leaving opaque images as they are
+func flatten(img image.Image, background color.Color) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+	bounds := img.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, bounds.Min, draw.Over)
+	return flat
+}
+
+// grayscale converts img to shades of gray
+func grayscale(img image.Image) *image.Gray {
+	bounds := img.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			gray.Set(x, y, color.GrayModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)))
+		}
+	}
+	return gray
+}
+
+// normalizeContrast stretches the luminance histogram of img over the full 0-255 range,
+// scaling every channel alike so colors keep their hue. Gray images stay gray.
+func normalizeContrast(img image.Image) image.Image {
+	bounds := img.Bounds()
+	var histogram [256]int
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			histogram[color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y]++
+		}
+	}
+	low, high := histogramRange(histogram, int(float64(bounds.Dx()*bounds.Dy())*contrastClip))
+	if high <= low {
+		return img
+	}
+
+	stretch := func(v uint8) uint8 {
+		return uint8(min(max((int(v)-low)*255/(high-low), 0), 255))
+	}
+	if gray, ok := img.(*image.Gray); ok {
+		// Go row by row: a sub-image shares the Pix, and the Stride, of its parent
+		normalized := image.NewGray(bounds)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			row := gray.Pix[gray.PixOffset(bounds.Min.X, y):][:bounds.Dx()]
+			normalizedRow := normalized.Pix[normalized.PixOffset(bounds.Min.X, y):][:bounds.Dx()]
+			for i, v := range row {
+				normalizedRow[i] = stretch(v)
+			}
+		}
+		return normalized
+	}
+
+	normalized := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			c := color.RGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.RGBA)
+			normalized.SetRGBA(x, y, color.RGBA{R: stretch(c.R), G: stretch(c.G), B: stretch(c.B), A: c.A})
+		}
+	}
+	return normalized
+}
+
+// histogramRange returns the lowest and highest levels once clip pixels are ignored at each end
+func histogramRange(histogram [256]int, clip int) (int, int) {
+	low, seen := 0, 0
+	for ; low < 255; low++ {
+		if seen += histogram[low]; seen > clip {
+			break
+		}
+	}
+	high, seen := 255, 0
+	for ; high > 0; high-- {
+		if seen += histogram[high]; seen > clip {
+			break
+		}
+	}
+	return low, high
+}
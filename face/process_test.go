@@ -0,0 +1,126 @@
+package face
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// dullPNG is a 100x100 PNG of colors in a narrow brightness band
+func dullPNG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(100 + x/2), G: uint8(100 + y/2), B: 120, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestCropTransforms(t *testing.T) {
+	bbox := boundingBox(0, 0, 1, 1)
+
+	crop, err := cropFace(dullPNG(t), bbox, 1, newCallOptions([]CallOption{WithGrayscale(), WithNormalizeContrast()}))
+	if err != nil {
+		t.Fatalf("error cropping face: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(crop))
+	if err != nil {
+		t.Fatalf("error decoding crop: %v", err)
+	}
+	gray, ok := img.(*image.Gray)
+	if !ok {
+		t.Fatalf("got %T crop, want grayscale", img)
+	}
+	low, high := uint8(255), uint8(0)
+	for _, v := range gray.Pix {
+		low, high = min(low, v), max(high, v)
+	}
+	if low > 5 || high < 250 {
+		t.Fatalf("crop brightness spans %d-%d, want it stretched over 0-255", low, high)
+	}
+
+	// Off by default
+	crop, err = cropFace(dullPNG(t), bbox, 1, callOptions{})
+	if err != nil {
+		t.Fatalf("error cropping face: %v", err)
+	}
+	img, err = png.Decode(bytes.NewReader(crop))
+	if err != nil {
+		t.Fatalf("error decoding crop: %v", err)
+	}
+	if r, g, b, _ := img.At(0, 0).RGBA(); r>>8 != 100 || g>>8 != 100 || b>>8 != 120 {
+		t.Fatalf("got corner color %d,%d,%d, want it untouched", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestCropBackground(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 100, 100))
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	transparent := buf.Bytes()
+
+	tests := []struct {
+		opts []CallOption
+		want color.RGBA
+	}{
+		{opts: []CallOption{WithOutputFormat(FormatJPEG)}, want: color.RGBA{R: 255, G: 255, B: 255, A: 255}},
+		{opts: []CallOption{WithOutputFormat(FormatJPEG), WithCropBackground(color.RGBA{R: 255, A: 255})}, want: color.RGBA{R: 255, A: 255}},
+	}
+	for _, tt := range tests {
+		crop, err := cropFace(transparent, boundingBox(0, 0, 1, 1), 1, newCallOptions(tt.opts))
+		if err != nil {
+			t.Fatalf("error cropping face: %v", err)
+		}
+		decoded, err := jpeg.Decode(bytes.NewReader(crop))
+		if err != nil {
+			t.Fatalf("error decoding crop: %v", err)
+		}
+		r, g, b, _ := decoded.At(50, 50).RGBA()
+		got := color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 255}
+		if diff := max(absDiff(got.R, tt.want.R), absDiff(got.G, tt.want.G), absDiff(got.B, tt.want.B)); diff > 8 {
+			t.Fatalf("got background %v, want %v", got, tt.want)
+		}
+	}
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func TestNormalizeContrastGraySubImage(t *testing.T) {
+	// The left half is dull, the right half is black and must not leak into the sub-image
+	parent := image.NewGray(image.Rect(0, 0, 100, 50))
+	for y := 0; y < 50; y++ {
+		for x := 0; x < 50; x++ {
+			parent.SetGray(x, y, color.Gray{Y: uint8(100 + x)})
+		}
+	}
+	sub := parent.SubImage(image.Rect(10, 5, 50, 45)).(*image.Gray)
+
+	normalized, ok := normalizeContrast(sub).(*image.Gray)
+	if !ok {
+		t.Fatalf("got %T, want grayscale", normalizeContrast(sub))
+	}
+	if normalized.Bounds() != sub.Bounds() {
+		t.Fatalf("got bounds %v, want %v", normalized.Bounds(), sub.Bounds())
+	}
+	for y := 5; y < 45; y++ {
+		if left, right := normalized.GrayAt(10, y).Y, normalized.GrayAt(49, y).Y; left > 5 || right < 250 {
+			t.Fatalf("row %d spans %d-%d, want it stretched over 0-255", y, left, right)
+		}
+	}
+}
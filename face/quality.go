@@ -0,0 +1,63 @@
+package face
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+	"github.com/samber/lo"
+)
+
+// IndexFaceWithQualityGate indexes the image and takes back every face whose Quality is below
+// minSharpness or minBrightness (0-100), so the collection only holds usable faces without a
+// separate DetectFaces call. It returns the FaceId of the most prominent face left, or
+// ErrLowQuality when none is. Faces Rekognition reports no quality for are kept.
+func (r *rekognitionFaceIndexer) IndexFaceWithQualityGate(ctx context.Context, image []byte, externalImageId string, collectionId string, minSharpness float32, minBrightness float32, opts ...CallOption) (string, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return "", err
+	}
+	o := newCallOptions(opts)
+	// Quality is part of the DEFAULT attributes
+	if len(o.detectionAttributes) == 0 {
+		o.detectionAttributes = []types.Attribute{types.AttributeDefault}
+	}
+	resp, err := r.indexFaceBytes(ctx, image, externalImageId, collectionId, o)
+	if err != nil {
+		return "", err
+	}
+
+	usable, low := lo.FilterReject(resp.FaceRecords, func(record types.FaceRecord, _ int) bool {
+		quality := faceQuality(record)
+		return quality == nil || aws.ToFloat32(quality.Sharpness) >= minSharpness && aws.ToFloat32(quality.Brightness) >= minBrightness
+	})
+	if len(low) > 0 {
+		_, err := invoke(ctx, r, "DeleteFaces", r.client.DeleteFaces, &rekognition.DeleteFacesInput{
+			CollectionId: aws.String(collectionId),
+			FaceIds: lo.Map(low, func(record types.FaceRecord, _ int) string {
+				return aws.ToString(record.Face.FaceId)
+			}),
+		}, o.apiOptions...)
+		if err != nil {
+			return "", fmt.Errorf("failed to remove low quality faces: %w", err)
+		}
+	}
+	if len(usable) == 0 {
+		quality := faceQuality(low[0])
+		return "", fmt.Errorf("failed to index face: %w: sharpness %.1f, brightness %.1f", ErrLowQuality, aws.ToFloat32(quality.Sharpness), aws.ToFloat32(quality.Brightness))
+	}
+
+	record := lo.MaxBy(usable, func(a, b types.FaceRecord) bool {
+		return boundingBoxArea(a.Face.BoundingBox) > boundingBoxArea(b.Face.BoundingBox)
+	})
+	return aws.ToString(record.Face.FaceId), nil
+}
+
+// faceQuality is the quality Rekognition measured for an indexed face, nil when it didn't
+func faceQuality(record types.FaceRecord) *types.ImageQuality {
+	if record.FaceDetail == nil {
+		return nil
+	}
+	return record.FaceDetail.Quality
+}
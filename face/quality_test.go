@@ -0,0 +1,66 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+// qualityRecord is an indexed face with the given bounding box and quality
+func qualityRecord(faceId string, bbox types.BoundingBox, sharpness, brightness float32) types.FaceRecord {
+	return types.FaceRecord{
+		Face: &types.Face{FaceId: aws.String(faceId), BoundingBox: &bbox},
+		FaceDetail: &types.FaceDetail{
+			BoundingBox: &bbox,
+			Quality:     &types.ImageQuality{Sharpness: aws.Float32(sharpness), Brightness: aws.Float32(brightness)},
+		},
+	}
+}
+
+func TestIndexFaceWithQualityGate(t *testing.T) {
+	var records []types.FaceRecord
+	var attributes []types.Attribute
+	var deleted []string
+	fake := &fakeRekognition{
+		indexFaces: func(input *rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			attributes = input.DetectionAttributes
+			return &rekognition.IndexFacesOutput{FaceRecords: records}, nil
+		},
+		deleteFaces: func(input *rekognition.DeleteFacesInput) (*rekognition.DeleteFacesOutput, error) {
+			deleted = append(deleted, input.FaceIds...)
+			return &rekognition.DeleteFacesOutput{DeletedFaces: input.FaceIds}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	ctx := context.TODO()
+	records = []types.FaceRecord{
+		qualityRecord("face-blurry", boundingBox(0, 0, 0.6, 0.6), 10, 80),
+		qualityRecord("face-sharp", boundingBox(0.6, 0.6, 0.3, 0.3), 70, 80),
+	}
+	faceId, err := faceIndexer.IndexFaceWithQualityGate(ctx, testJPEG(t, 100, 100), "photo_1", "event_1", 50, 40)
+	if err != nil {
+		t.Fatalf("error indexing face: %v", err)
+	}
+	if faceId != "face-sharp" || !reflect.DeepEqual(deleted, []string{"face-blurry"}) {
+		t.Fatalf("got %s and deleted %v, want face-sharp and face-blurry deleted", faceId, deleted)
+	}
+	if !reflect.DeepEqual(attributes, []types.Attribute{types.AttributeDefault}) {
+		t.Fatalf("got attributes %v, want DEFAULT", attributes)
+	}
+
+	deleted = nil
+	records = []types.FaceRecord{qualityRecord("face-dark", boundingBox(0.2, 0.2, 0.5, 0.5), 90, 10)}
+	_, err = faceIndexer.IndexFaceWithQualityGate(ctx, testJPEG(t, 100, 100), "photo_2", "event_1", 50, 40)
+	if !errors.Is(err, ErrLowQuality) {
+		t.Fatalf("got %v, want ErrLowQuality", err)
+	}
+	if !reflect.DeepEqual(deleted, []string{"face-dark"}) {
+		t.Fatalf("deleted %v, want face-dark", deleted)
+	}
+}
@@ -0,0 +1,33 @@
+package face
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+// IndexFaceRaw is IndexFace returning the full IndexFacesOutput, an escape hatch for fields the
+// package doesn't expose, e.g. FaceDetail.Sunglasses with WithDetectionAttributes(types.AttributeAll).
+// The collection is ensured and the call options are applied as for IndexFace, and it fails
+// like IndexFace, with an UnindexedFacesError, when no face is indexed.
+func (r *rekognitionFaceIndexer) IndexFaceRaw(ctx context.Context, image []byte, externalImageId string, collectionId string, opts ...CallOption) (*rekognition.IndexFacesOutput, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return nil, err
+	}
+	return r.indexFaceBytes(ctx, image, externalImageId, collectionId, newCallOptions(opts))
+}
+
+// SearchFacesByImageRaw searches the largest face of the image against the collection without
+// indexing it and returns the full SearchFacesByImageOutput, unfiltered by WithMinSimilarity.
+func (r *rekognitionFaceIndexer) SearchFacesByImageRaw(ctx context.Context, image []byte, collectionId string, opts ...CallOption) (*rekognition.SearchFacesByImageOutput, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return nil, err
+	}
+	// Reject images Rekognition can't read before making any call
+	if _, err := validateImage(image); err != nil {
+		return nil, fmt.Errorf("failed to search face by image: %w", err)
+	}
+	return r.searchFacesByImage(ctx, &types.Image{Bytes: image}, collectionId, 0, newCallOptions(opts))
+}
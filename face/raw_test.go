@@ -0,0 +1,53 @@
+package face
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func TestIndexFaceRaw(t *testing.T) {
+	fake := &fakeRekognition{
+		indexFaces: func(input *rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			return &rekognition.IndexFacesOutput{
+				FaceRecords: []types.FaceRecord{{
+					Face:       &types.Face{FaceId: aws.String("face-1")},
+					FaceDetail: &types.FaceDetail{Sunglasses: &types.Sunglasses{Value: true}},
+				}},
+				FaceModelVersion: aws.String("7.0"),
+			}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	resp, err := faceIndexer.IndexFaceRaw(context.TODO(), testJPEG(t, 100, 100), "photo_1", "event_1", WithDetectionAttributes(types.AttributeAll))
+	if err != nil {
+		t.Fatalf("error indexing face: %v", err)
+	}
+	if aws.ToString(resp.FaceModelVersion) != "7.0" || !resp.FaceRecords[0].FaceDetail.Sunglasses.Value {
+		t.Fatalf("got response %+v, want the full IndexFaces output", resp)
+	}
+}
+
+func TestSearchFacesByImageRaw(t *testing.T) {
+	fake := &fakeRekognition{
+		searchFacesByImage: func(*rekognition.SearchFacesByImageInput) (*rekognition.SearchFacesByImageOutput, error) {
+			return &rekognition.SearchFacesByImageOutput{
+				FaceMatches:            []types.FaceMatch{faceMatch("face-1", "photo_1", 99), faceMatch("face-2", "photo_2", 50)},
+				SearchedFaceConfidence: aws.Float32(99.9),
+			}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	resp, err := faceIndexer.SearchFacesByImageRaw(context.TODO(), testJPEG(t, 100, 100), "event_1", WithMinSimilarity(90))
+	if err != nil {
+		t.Fatalf("error searching face: %v", err)
+	}
+	if len(resp.FaceMatches) != 2 || aws.ToFloat32(resp.SearchedFaceConfidence) != 99.9 {
+		t.Fatalf("got response %+v, want the full SearchFacesByImage output", resp)
+	}
+}
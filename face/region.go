@@ -0,0 +1,146 @@
+package face
+
+import (
+	"context"
+	"fmt"
+	"image"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+// searchRegionScale grows a region searched by SearchFaceInRegion, so a tight face box
+// tapped by a user keeps the margin Rekognition needs to detect the face in the crop
+const searchRegionScale = 1.2
+
+// IndexedFace is a face enrolled by IndexFaces and where it is in the image.
+type IndexedFace struct {
+	FaceId      string            `json:"faceId"`
+	BoundingBox types.BoundingBox `json:"boundingBox"`
+	// Confidence is how sure Rekognition is that this is a face, 0 when it didn't say
+	Confidence float32 `json:"confidence"`
+	// Face and FaceDetail are the stored face and its analysis, e.g. quality, pose and emotions
+	// with WithDetectionAttributes, as IndexFaces returned them. IndexFaceDetailed sets them with
+	// WithFaceRecords; either is nil when Rekognition didn't return it.
+	Face       *types.Face       `json:"face,omitempty"`
+	FaceDetail *types.FaceDetail `json:"faceDetail,omitempty"`
+}
+
+// RegionSearchResult is the outcome of SearchFaceInRegion.
+type RegionSearchResult struct {
+	// SearchedFaceBoundingBox is the face that was searched, in full-image coordinates
+	SearchedFaceBoundingBox types.BoundingBox `json:"searchedFaceBoundingBox"`
+	Matches                 []FaceMatchResult `json:"matches"`
+	// LowQualityInput is set when Rekognition wasn't confident the searched face is a face,
+	// see WithLowQualityConfidence
+	LowQualityInput bool `json:"lowQualityInput"`
+}
+
+// IndexFaceInRegion indexes only the faces inside region, e.g. the frame of a photobooth,
+// ignoring bystanders around it. region is normalized (0-1) like a Rekognition bounding
+// box and relative to the upright image. The returned bounding boxes are translated back
+// to full-image coordinates.
+func (r *rekognitionFaceIndexer) IndexFaceInRegion(ctx context.Context, image []byte, region types.BoundingBox, externalImageId string, collectionId string, opts ...CallOption) ([]IndexedFace, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return nil, err
+	}
+	crop, err := cropRegion(image, region, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to index face in region: %w", err)
+	}
+
+	resp, err := r.indexFaceBytes(ctx, crop.bytes, externalImageId, collectionId, newCallOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	faces := make([]IndexedFace, 0, len(resp.FaceRecords))
+	for _, record := range resp.FaceRecords {
+		face := indexedFace(record)
+		if face.BoundingBox != (types.BoundingBox{}) {
+			face.BoundingBox = crop.toImage(face.BoundingBox)
+		}
+		faces = append(faces, face)
+	}
+	return faces, nil
+}
+
+// SearchFaceInRegion searches the largest face inside region against the collection, e.g.
+// the face a user tapped in a group photo rather than the largest one. region is normalized
+// (0-1) and relative to the upright image; it is expanded slightly (by searchRegionScale) before
+// cropping. The searched face's bounding box is translated back to full-image coordinates.
+func (r *rekognitionFaceIndexer) SearchFaceInRegion(ctx context.Context, image []byte, region types.BoundingBox, collectionId string, opts ...CallOption) (RegionSearchResult, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return RegionSearchResult{}, err
+	}
+	crop, err := cropRegion(image, region, searchRegionScale)
+	if err != nil {
+		return RegionSearchResult{}, fmt.Errorf("failed to search face in region: %w", err)
+	}
+	// Reject crops Rekognition can't read, e.g. a region that is too small
+	if _, err := validateImage(crop.bytes); err != nil {
+		return RegionSearchResult{}, fmt.Errorf("failed to search face in region: %w", err)
+	}
+
+	o := newCallOptions(opts)
+	resp, err := r.searchFacesByImage(ctx, &types.Image{Bytes: crop.bytes}, collectionId, 0, o)
+	if err != nil {
+		return RegionSearchResult{}, err
+	}
+
+	result := RegionSearchResult{
+		Matches:         faceMatchResults(resp.FaceMatches, o),
+		LowQualityInput: o.lowQualityInput(resp.SearchedFaceConfidence),
+	}
+	if resp.SearchedFaceBoundingBox != nil {
+		result.SearchedFaceBoundingBox = crop.toImage(*resp.SearchedFaceBoundingBox)
+	}
+	return result, noMatchError(len(result.Matches), o)
+}
+
+// regionCrop is an image cropped to a region of interest, with what is needed to
+// translate bounding boxes found in the crop back to the full image
+type regionCrop struct {
+	bytes  []byte
+	rect   image.Rectangle
+	bounds image.Rectangle
+}
+
+// cropRegion decodes the image upright and crops it to the normalized region grown around its
+// center by scale, keeping the source format
+func cropRegion(imageBytes []byte, region types.BoundingBox, scale float64) (regionCrop, error) {
+	if region.Left == nil || region.Top == nil || region.Width == nil || region.Height == nil {
+		return regionCrop{}, fmt.Errorf("%w: missing coordinates", ErrInvalidBoundingBox)
+	}
+
+	img, format, err := decodeUpright(imageBytes)
+	if err != nil {
+		return regionCrop{}, err
+	}
+	bounds := img.Bounds()
+	rect := scaledRect(bounds, region, scale).Intersect(bounds)
+	if rect.Empty() {
+		return regionCrop{}, fmt.Errorf("%w: region %v is outside the %dx%d image", ErrInvalidBoundingBox, bboxString(region), bounds.Dx(), bounds.Dy())
+	}
+
+	cropped, err := encodeImage(cropRect(img, rect), format)
+	if err != nil {
+		return regionCrop{}, err
+	}
+	return regionCrop{bytes: cropped, rect: rect, bounds: bounds}, nil
+}
+
+// toImage translates a bounding box normalized to the crop into one normalized to the full image
+func (c regionCrop) toImage(bbox types.BoundingBox) types.BoundingBox {
+	width := float32(c.bounds.Dx())
+	height := float32(c.bounds.Dy())
+	offsetX := float32(c.rect.Min.X - c.bounds.Min.X)
+	offsetY := float32(c.rect.Min.Y - c.bounds.Min.Y)
+
+	return types.BoundingBox{
+		Left:   aws.Float32((offsetX + aws.ToFloat32(bbox.Left)*float32(c.rect.Dx())) / width),
+		Top:    aws.Float32((offsetY + aws.ToFloat32(bbox.Top)*float32(c.rect.Dy())) / height),
+		Width:  aws.Float32(aws.ToFloat32(bbox.Width) * float32(c.rect.Dx()) / width),
+		Height: aws.Float32(aws.ToFloat32(bbox.Height) * float32(c.rect.Dy()) / height),
+	}
+}
@@ -0,0 +1,112 @@
+package face
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/jpeg"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func TestIndexFaceInRegion(t *testing.T) {
+	var gotWidth, gotHeight int
+	fake := &fakeRekognition{
+		indexFaces: func(input *rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			config, err := jpeg.DecodeConfig(bytes.NewReader(input.Image.Bytes))
+			if err != nil {
+				return nil, err
+			}
+			gotWidth, gotHeight = config.Width, config.Height
+			bbox := boundingBox(0.5, 0.5, 0.25, 0.25)
+			return &rekognition.IndexFacesOutput{FaceRecords: []types.FaceRecord{
+				{Face: &types.Face{FaceId: aws.String("face-1"), BoundingBox: &bbox, Confidence: aws.Float32(99)}},
+			}}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	faces, err := faceIndexer.IndexFaceInRegion(context.TODO(), testJPEG(t, 400, 200), boundingBox(0.5, 0, 0.5, 1), "photo_1", "event_1")
+	if err != nil {
+		t.Fatalf("error indexing face in region: %v", err)
+	}
+	if gotWidth != 200 || gotHeight != 200 {
+		t.Fatalf("indexed a %dx%d image, want the 200x200 region", gotWidth, gotHeight)
+	}
+	want := []IndexedFace{{FaceId: "face-1", BoundingBox: boundingBox(0.75, 0.5, 0.125, 0.25), Confidence: 99}}
+	if !reflect.DeepEqual(faces, want) {
+		t.Fatalf("got faces %v, want %v", faces, want)
+	}
+}
+
+func TestSearchFaceInRegion(t *testing.T) {
+	fake := &fakeRekognition{
+		searchFacesByImage: func(*rekognition.SearchFacesByImageInput) (*rekognition.SearchFacesByImageOutput, error) {
+			bbox := boundingBox(0, 0, 0.5, 0.5)
+			return &rekognition.SearchFacesByImageOutput{
+				SearchedFaceBoundingBox: &bbox,
+				FaceMatches:             []types.FaceMatch{faceMatch("face-1", "photo_1", 99)},
+			}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	result, err := faceIndexer.SearchFaceInRegion(context.TODO(), testJPEG(t, 200, 400), boundingBox(0, 0.5, 1, 0.5), "event_1")
+	if err != nil {
+		t.Fatalf("error searching face in region: %v", err)
+	}
+	want := RegionSearchResult{
+		// The region is grown to rows 180-400, 220 pixels tall
+		SearchedFaceBoundingBox: boundingBox(0, 0.45, 0.5, 0.275),
+		Matches:                 []FaceMatchResult{{FaceId: "face-1", ExternalImageId: "photo_1", Similarity: 99, Confidence: 99.5}},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Fatalf("got result %v, want %v", result, want)
+	}
+}
+
+func TestRegionOutsideImage(t *testing.T) {
+	fake := &fakeRekognition{}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	_, err := faceIndexer.SearchFaceInRegion(context.TODO(), testJPEG(t, 200, 200), boundingBox(1.5, 1.5, 0.5, 0.5), "event_1")
+	if !errors.Is(err, ErrInvalidBoundingBox) {
+		t.Fatalf("got error %v, want %v", err, ErrInvalidBoundingBox)
+	}
+	if fake.count("SearchFacesByImage") != 0 {
+		t.Fatal("searched a region outside the image")
+	}
+}
+
+func TestSearchFaceInRegionLowQualityInput(t *testing.T) {
+	fake := &fakeRekognition{
+		searchFacesByImage: func(*rekognition.SearchFacesByImageInput) (*rekognition.SearchFacesByImageOutput, error) {
+			return &rekognition.SearchFacesByImageOutput{SearchedFaceConfidence: aws.Float32(85)}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	tests := []struct {
+		name string
+		opts []CallOption
+		want bool
+	}{
+		{"default threshold", nil, true},
+		{"lower threshold", []CallOption{WithLowQualityConfidence(80)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := faceIndexer.SearchFaceInRegion(context.TODO(), testJPEG(t, 200, 200), boundingBox(0, 0, 1, 1), "event_1", tt.opts...)
+			if err != nil {
+				t.Fatalf("error searching face in region: %v", err)
+			}
+			if result.LowQualityInput != tt.want {
+				t.Fatalf("got LowQualityInput %v, want %v", result.LowQualityInput, tt.want)
+			}
+		})
+	}
+}
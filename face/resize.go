@@ -0,0 +1,83 @@
+package face
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"log/slog"
+
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+	xdraw "golang.org/x/image/draw"
+)
+
+// downscaleFactor is how much each side shrinks when Rekognition rejects an image as too large
+const downscaleFactor = 0.5
+
+// resizeImage scales img to width x height
+func resizeImage(img image.Image, width int, height int) image.Image {
+	resized := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.ApproxBiLinear.Scale(resized, resized.Bounds(), img, img.Bounds(), xdraw.Src, nil)
+	return resized
+}
+
+// exceedsDimension reports whether the longest side of the image is over maxDimension pixels.
+// Images whose size can't be read are left to fail where they are decoded.
+func exceedsDimension(imageBytes []byte, maxDimension int) bool {
+	config, _, err := image.DecodeConfig(bytes.NewReader(imageBytes))
+	if err != nil {
+		return false
+	}
+	return max(config.Width, config.Height) > maxDimension
+}
+
+// fitWithin scales img down, keeping its aspect ratio, so its longest side is maxDimension pixels
+func fitWithin(img image.Image, maxDimension int) image.Image {
+	bounds := img.Bounds()
+	longest := max(bounds.Dx(), bounds.Dy())
+	if longest <= maxDimension {
+		return img
+	}
+	width := max(1, bounds.Dx()*maxDimension/longest)
+	height := max(1, bounds.Dy()*maxDimension/longest)
+	return resizeImage(img, width, height)
+}
+
+// downscaleImage returns an upright JPEG copy of the image with each side scaled by factor.
+// Bounding boxes are normalized to the upright image, so they stay valid for the original.
+func downscaleImage(imageBytes []byte, factor float64) ([]byte, error) {
+	img, _, err := decodeUpright(imageBytes)
+	if err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	width := max(1, int(float64(bounds.Dx())*factor))
+	height := max(1, int(float64(bounds.Dy())*factor))
+	return encodeJPEG(resizeImage(img, width, height))
+}
+
+// retryDownscaled calls the operation with the image bytes and, when Rekognition
+// rejects them with ImageTooLargeException, retries once with a downscaled copy.
+// Some images pass the byte size check but still exceed Rekognition's pixel limits.
+func retryDownscaled[Out any](logger *slog.Logger, imageBytes []byte, call func(imageBytes []byte) (Out, error)) (Out, error) {
+	resp, err := call(imageBytes)
+	var tooLarge *types.ImageTooLargeException
+	if err == nil || len(imageBytes) == 0 || !errors.As(err, &tooLarge) {
+		return resp, err
+	}
+
+	smaller, downscaleErr := downscaleImage(imageBytes, downscaleFactor)
+	if downscaleErr != nil {
+		return resp, fmt.Errorf("%w (downscale failed: %v)", err, downscaleErr)
+	}
+	logger.Info("Image too large for Rekognition, retry once with a downscaled copy", "bytes", len(smaller))
+	return call(smaller)
+}
+
+// withBytes returns image with its bytes replaced, leaving S3 images untouched
+func withBytes(image *types.Image, imageBytes []byte) *types.Image {
+	if image.Bytes == nil {
+		return image
+	}
+	return &types.Image{Bytes: imageBytes}
+}
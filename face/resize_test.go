@@ -0,0 +1,61 @@
+package face
+
+import (
+	"bytes"
+	"context"
+	"image/jpeg"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func TestIndexFaceRetriesDownscaledWhenTooLarge(t *testing.T) {
+	var widths []int
+	fake := &fakeRekognition{
+		indexFaces: func(input *rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			config, err := jpeg.DecodeConfig(bytes.NewReader(input.Image.Bytes))
+			if err != nil {
+				t.Fatalf("error decoding sent image: %v", err)
+			}
+			widths = append(widths, config.Width)
+			if config.Width > 200 {
+				return nil, awsOperationError("IndexFaces", "req-1", 400, &types.ImageTooLargeException{})
+			}
+			return (&fakeRekognition{}).IndexFaces(context.TODO(), input)
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	if err := faceIndexer.IndexFace(context.TODO(), testJPEG(t, 400, 300), "photo_1", "event_1"); err != nil {
+		t.Fatalf("error indexing face: %v", err)
+	}
+	if len(widths) != 2 || widths[0] != 400 || widths[1] != 200 {
+		t.Fatalf("got widths %v, want [400 200]", widths)
+	}
+}
+
+func TestMaxDimension(t *testing.T) {
+	var sizes [][2]int
+	fake := &fakeRekognition{
+		indexFaces: func(input *rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			config, err := jpeg.DecodeConfig(bytes.NewReader(input.Image.Bytes))
+			if err != nil {
+				t.Fatalf("error decoding sent image: %v", err)
+			}
+			sizes = append(sizes, [2]int{config.Width, config.Height})
+			return (&fakeRekognition{}).IndexFaces(context.TODO(), input)
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake, options: newOptions([]Option{WithMaxDimension(200)})}
+
+	for _, img := range [][]byte{testJPEG(t, 400, 300), testJPEG(t, 300, 600), testJPEG(t, 150, 100)} {
+		if err := faceIndexer.IndexFace(context.TODO(), img, "photo_1", "event_1"); err != nil {
+			t.Fatalf("error indexing face: %v", err)
+		}
+	}
+	if want := [][2]int{{200, 150}, {100, 200}, {150, 100}}; !reflect.DeepEqual(sizes, want) {
+		t.Fatalf("got sizes %v, want %v", sizes, want)
+	}
+}
@@ -0,0 +1,43 @@
+package face
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// maxS3ImageBytes is the largest S3 object Rekognition accepts as an image
+const maxS3ImageBytes = 15 * 1024 * 1024
+
+// S3HeadObjectAPI is the S3 operation used to check the size of images. *s3.Client satisfies it.
+type S3HeadObjectAPI interface {
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+}
+
+// s3Image returns the Rekognition image of an S3 object. With WithS3SizeCheck the object is
+// checked first, and one over the 15MB Rekognition accepts fails with ErrImageTooLarge.
+func (r *rekognitionFaceIndexer) s3Image(ctx context.Context, s3Bucket string, s3Key string) (*types.Image, error) {
+	if client := r.options.s3SizeCheck; client != nil {
+		resp, err := client.HeadObject(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String(s3Bucket),
+			Key:    aws.String(s3Key),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to check size of s3://%s/%s: %w", s3Bucket, s3Key, err)
+		}
+		if size := aws.ToInt64(resp.ContentLength); size > maxS3ImageBytes {
+			return nil, fmt.Errorf("%w: s3://%s/%s is %d bytes, more than %d", ErrImageTooLarge, s3Bucket, s3Key, size, maxS3ImageBytes)
+		}
+	}
+
+	// Prepare the image input using S3Object
+	return &types.Image{
+		S3Object: &types.S3Object{
+			Bucket: aws.String(s3Bucket),
+			Name:   aws.String(s3Key),
+		},
+	}, nil
+}
@@ -0,0 +1,44 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeS3Head reports the size of every object as size
+type fakeS3Head struct {
+	size int64
+}
+
+func (f *fakeS3Head) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	return &s3.HeadObjectOutput{ContentLength: aws.Int64(f.size)}, nil
+}
+
+func TestS3SizeCheck(t *testing.T) {
+	fake := &fakeRekognition{}
+	head := &fakeS3Head{size: 20 * 1024 * 1024}
+	faceIndexer := &rekognitionFaceIndexer{client: fake, options: newOptions([]Option{WithS3SizeCheck(head)})}
+
+	ctx := context.TODO()
+	if err := faceIndexer.IndexFaceWithBucket(ctx, "photos", "large.jpg", "photo_1", "event_1"); !errors.Is(err, ErrImageTooLarge) {
+		t.Fatalf("got error %v indexing, want %v", err, ErrImageTooLarge)
+	}
+	if _, err := faceIndexer.SearchFaceWithBucket(ctx, "photos", "large.jpg", "event_1"); !errors.Is(err, ErrImageTooLarge) {
+		t.Fatalf("got error %v searching, want %v", err, ErrImageTooLarge)
+	}
+	if fake.count("IndexFaces") != 0 || fake.count("SearchFacesByImage") != 0 {
+		t.Fatal("Rekognition was called for an image over 15MB")
+	}
+
+	head.size = 10 * 1024 * 1024
+	if err := faceIndexer.IndexFaceWithBucket(ctx, "photos", "small.jpg", "photo_1", "event_1"); err != nil {
+		t.Fatalf("error indexing face: %v", err)
+	}
+	if got := fake.count("IndexFaces"); got != 1 {
+		t.Fatalf("got %d IndexFaces calls, want 1", got)
+	}
+}
@@ -0,0 +1,263 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+	"github.com/samber/lo"
+)
+
+// CollectionSearchResult is the outcome of searching one collection in SearchFaceAcrossCollections.
+type CollectionSearchResult struct {
+	// FaceModelVersion is the face model of the collection. Similarities computed by
+	// different models aren't comparable.
+	FaceModelVersion string            `json:"faceModelVersion"`
+	Matches          []FaceMatchResult `json:"matches"`
+	// LowQualityInput is set when Rekognition wasn't confident the searched face is a face,
+	// see WithLowQualityConfidence
+	LowQualityInput bool `json:"lowQualityInput"`
+}
+
+// SearchFaceAcrossCollections searches the largest face in image against every collection
+// concurrently and returns the results keyed by collection. A collection that doesn't exist
+// is logged and left out of the result instead of failing the whole search. Pass a threshold
+// of 0 to use Rekognition's default FaceMatchThreshold. A warning is logged when the
+// collections don't all use the same face model, as their similarities can't be ranked
+// against each other.
+func (r *rekognitionFaceIndexer) SearchFaceAcrossCollections(ctx context.Context, image []byte, collectionIds []string, threshold float32, opts ...CallOption) (map[string]CollectionSearchResult, error) {
+	for _, collectionId := range collectionIds {
+		if err := validateCollectionId(collectionId); err != nil {
+			return nil, err
+		}
+	}
+	// Reject images Rekognition can't read before making any call
+	if _, err := validateImage(image); err != nil {
+		return nil, fmt.Errorf("failed to search face across collections: %w", err)
+	}
+	o := newCallOptions(opts)
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]CollectionSearchResult, len(collectionIds))
+		errs    []error
+	)
+	for _, collectionId := range collectionIds {
+		wg.Add(1)
+		go func(collectionId string) {
+			defer wg.Done()
+			resp, err := r.searchFacesByImage(ctx, &types.Image{Bytes: image}, collectionId, threshold, o)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				var notFound *types.ResourceNotFoundException
+				if errors.As(err, &notFound) {
+					r.logger(ctx).Warn("Collection does not exist, skip it in search across collections", "collectionId", collectionId)
+					return
+				}
+				errs = append(errs, fmt.Errorf("collection %s: %w", collectionId, err))
+				return
+			}
+			results[collectionId] = CollectionSearchResult{
+				FaceModelVersion: aws.ToString(resp.FaceModelVersion),
+				Matches:          faceMatchResults(resp.FaceMatches, o),
+				LowQualityInput:  o.lowQualityInput(resp.SearchedFaceConfidence),
+			}
+		}(collectionId)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to search face across collections: %w", errors.Join(errs...))
+	}
+	if versions := faceModelVersions(results); len(versions) > 1 {
+		r.logger(ctx).Warn("Collections use different face model versions, similarities aren't comparable across them", "faceModelVersions", versions)
+	}
+	matches := 0
+	for _, result := range results {
+		matches += len(result.Matches)
+	}
+	return results, noMatchError(matches, o)
+}
+
+// faceModelVersions returns the collection ids keyed by the face model version they use,
+// leaving out collections whose version wasn't reported
+func faceModelVersions(results map[string]CollectionSearchResult) map[string][]string {
+	versions := make(map[string][]string)
+	for collectionId, result := range results {
+		if result.FaceModelVersion == "" {
+			continue
+		}
+		versions[result.FaceModelVersion] = append(versions[result.FaceModelVersion], collectionId)
+	}
+	for _, collectionIds := range versions {
+		slices.Sort(collectionIds)
+	}
+	return versions
+}
+
+// SearchSelfieFace searches the largest face of the selfie against the collection without indexing
+// it, and returns the matches together with the searched face's bounding box and a crop of it. It is
+// the read-only alternative to SearchAndIndexSelfieFace and should be preferred when the selfie
+// doesn't need to be enrolled. Rekognition searches the largest face; with WithFaceSelector the
+// faces are detected first and the selected one is searched instead.
+func (r *rekognitionFaceIndexer) SearchSelfieFace(ctx context.Context, image []byte, collectionId string, opts ...CallOption) ([]FaceMatchResult, types.BoundingBox, []byte, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return nil, types.BoundingBox{}, nil, err
+	}
+	// Reject images Rekognition can't read before making any call
+	if _, err := validateImage(image); err != nil {
+		return nil, types.BoundingBox{}, nil, fmt.Errorf("failed to search selfie face: %w", err)
+	}
+	o := newCallOptions(opts)
+	if o.faceSelector != nil {
+		matches, searchedBox, crop, err := r.searchSelectedFace(ctx, image, collectionId, o)
+		if err != nil {
+			return matches, searchedBox, crop, fmt.Errorf("failed to search selfie face: %w", err)
+		}
+		return matches, searchedBox, crop, noMatchError(len(matches), o)
+	}
+
+	resp, err := r.searchFacesByImage(ctx, &types.Image{Bytes: image}, collectionId, 0, o)
+	if err != nil {
+		return nil, types.BoundingBox{}, nil, fmt.Errorf("failed to search selfie face: %w", err)
+	}
+	matches := faceMatchResults(resp.FaceMatches, o)
+	if resp.SearchedFaceBoundingBox == nil {
+		return matches, types.BoundingBox{}, nil, noMatchError(len(matches), o)
+	}
+
+	// Bounding boxes refer to the upright image, so rotate before cropping
+	searchedBox := *resp.SearchedFaceBoundingBox
+	crop, err := cropFace(image, searchedBox, 1, o)
+	if err != nil {
+		return matches, searchedBox, nil, fmt.Errorf("failed to crop selfie face: %w", err)
+	}
+	return matches, searchedBox, crop, noMatchError(len(matches), o)
+}
+
+// SearchManyByFaceIds searches each stored face against the collection with up to concurrency
+// searches at a time, 1 when unset, and returns the matched ExternalImageIds keyed by FaceId.
+// Each face is searched once, without waiting for just-indexed faces to become searchable.
+// Faces whose search failed are left out of the map and reported in the returned error.
+func (r *rekognitionFaceIndexer) SearchManyByFaceIds(ctx context.Context, faceIds []string, collectionId string, concurrency int, opts ...CallOption) (map[string][]string, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return nil, err
+	}
+	o := newCallOptions(opts)
+	faceIds = lo.Uniq(faceIds)
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string][]string, len(faceIds))
+		errs    []error
+	)
+	work := make(chan string)
+	for w := 0; w < min(max(concurrency, 1), len(faceIds)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for faceId := range work {
+				resp, err := invoke(ctx, r, "SearchFaces", r.client.SearchFaces, searchFacesInput(collectionId, faceId, o), o.apiOptions...)
+
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("face %s: %w", faceId, err))
+				} else {
+					results[faceId] = matchedExternalImageIds(resp.FaceMatches, o)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var ctxErr error
+	for _, faceId := range faceIds {
+		if ctxErr = ctx.Err(); ctxErr != nil {
+			break
+		}
+		work <- faceId
+	}
+	close(work)
+	wg.Wait()
+
+	if ctxErr != nil {
+		errs = append(errs, ctxErr)
+	}
+	if len(errs) > 0 {
+		return results, fmt.Errorf("failed to search faces by id: %w", errors.Join(errs...))
+	}
+	matches := 0
+	for _, externalImageIds := range results {
+		matches += len(externalImageIds)
+	}
+	return results, noMatchError(matches, o)
+}
+
+// searchFacesByImage searches the largest face in image against the collection
+func (r *rekognitionFaceIndexer) searchFacesByImage(ctx context.Context, image *types.Image, collectionId string, threshold float32, o callOptions) (*rekognition.SearchFacesByImageOutput, error) {
+	image, err := r.uploadImage(image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search face by image: %w", err)
+	}
+	input := &rekognition.SearchFacesByImageInput{
+		CollectionId: aws.String(collectionId),
+		Image:        image,
+	}
+	if threshold > 0 {
+		input.FaceMatchThreshold = aws.Float32(threshold)
+	}
+
+	// Retry once downscaled when the image bytes are too large
+	resp, err := retryDownscaled(r.logger(ctx), image.Bytes, func(imageBytes []byte) (*rekognition.SearchFacesByImageOutput, error) {
+		input.Image = withBytes(image, imageBytes)
+		return invoke(ctx, r, "SearchFacesByImage", r.client.SearchFacesByImage, input, o.apiOptions...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search face by image: %w", err)
+	}
+	return resp, nil
+}
+
+// DefaultLowQualityConfidence is the SearchedFaceConfidence below which a searched face is
+// reported as low quality when WithLowQualityConfidence isn't set
+const DefaultLowQualityConfidence float32 = 90
+
+// lowQualityInput reports whether the searched face's confidence is below the call's
+// threshold. A response without a confidence isn't flagged.
+func (o callOptions) lowQualityInput(searchedFaceConfidence *float32) bool {
+	if searchedFaceConfidence == nil {
+		return false
+	}
+	threshold := o.lowQualityConfidence
+	if threshold <= 0 {
+		threshold = DefaultLowQualityConfidence
+	}
+	return *searchedFaceConfidence < threshold
+}
+
+// maxSearchFaces is the most matches SearchFaces returns, in a single unpaginated response
+const maxSearchFaces = 4096
+
+// searchFacesInput is the SearchFaces input for the stored face, with the call's MaxFaces and threshold
+func searchFacesInput(collectionId string, faceId string, o callOptions) *rekognition.SearchFacesInput {
+	input := &rekognition.SearchFacesInput{
+		CollectionId: aws.String(collectionId),
+		FaceId:       aws.String(faceId),
+	}
+	if o.maxFaces > 0 {
+		input.MaxFaces = aws.Int32(min(o.maxFaces, maxSearchFaces))
+	}
+	if o.faceMatchThreshold > 0 {
+		input.FaceMatchThreshold = aws.Float32(o.faceMatchThreshold)
+	}
+	return input
+}
@@ -0,0 +1,389 @@
+package face
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func faceMatch(faceId, externalImageId string, similarity float32) types.FaceMatch {
+	return types.FaceMatch{
+		Face: &types.Face{
+			FaceId:          aws.String(faceId),
+			ExternalImageId: aws.String(externalImageId),
+			Confidence:      aws.Float32(99.5),
+		},
+		Similarity: aws.Float32(similarity),
+	}
+}
+
+func TestSearchFaceWithBucketMinSimilarity(t *testing.T) {
+	fake := &fakeRekognition{
+		searchFacesByImage: func(*rekognition.SearchFacesByImageInput) (*rekognition.SearchFacesByImageOutput, error) {
+			return &rekognition.SearchFacesByImageOutput{
+				FaceMatches: []types.FaceMatch{
+					faceMatch("face-1", "photo_1", 99),
+					faceMatch("face-2", "photo_1", 97),
+					faceMatch("face-3", "photo_2", 85),
+					faceMatch("face-4", "photo_3", 96),
+				},
+			}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	ctx := context.TODO()
+	all, err := faceIndexer.SearchFaceWithBucket(ctx, "bucket", "key.jpg", "event_1")
+	if err != nil {
+		t.Fatalf("error searching for face: %v", err)
+	}
+	if want := []string{"photo_1", "photo_2", "photo_3"}; !reflect.DeepEqual(all, want) {
+		t.Fatalf("got %v, want %v", all, want)
+	}
+
+	strict, err := faceIndexer.SearchFaceWithBucket(ctx, "bucket", "key.jpg", "event_1", WithMinSimilarity(95))
+	if err != nil {
+		t.Fatalf("error searching for face: %v", err)
+	}
+	if want := []string{"photo_1", "photo_3"}; !reflect.DeepEqual(strict, want) {
+		t.Fatalf("got %v, want %v", strict, want)
+	}
+}
+
+func TestSearchFaceMatchesWithBucket(t *testing.T) {
+	fake := &fakeRekognition{
+		searchFacesByImage: func(*rekognition.SearchFacesByImageInput) (*rekognition.SearchFacesByImageOutput, error) {
+			return &rekognition.SearchFacesByImageOutput{
+				FaceMatches: []types.FaceMatch{
+					faceMatch("face-1", "photo_1", 99),
+					faceMatch("face-2", "photo_1", 97),
+					faceMatch("face-3", "photo_2", 85),
+				},
+			}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	ctx := context.TODO()
+	matches, err := faceIndexer.SearchFaceMatchesWithBucket(ctx, "bucket", "key.jpg", "event_1", WithMinSimilarity(90))
+	if err != nil {
+		t.Fatalf("error searching for face: %v", err)
+	}
+	want := []FaceMatchResult{
+		{FaceId: "face-1", ExternalImageId: "photo_1", Similarity: 99, Confidence: 99.5},
+		{FaceId: "face-2", ExternalImageId: "photo_1", Similarity: 97, Confidence: 99.5},
+	}
+	if !reflect.DeepEqual(matches, want) {
+		t.Fatalf("got %+v, want %+v", matches, want)
+	}
+}
+
+func TestSearchPhotoMatchesWithBucket(t *testing.T) {
+	fake := &fakeRekognition{
+		searchFacesByImage: func(*rekognition.SearchFacesByImageInput) (*rekognition.SearchFacesByImageOutput, error) {
+			return &rekognition.SearchFacesByImageOutput{
+				FaceMatches: []types.FaceMatch{
+					faceMatch("face-3", "photo_2", 85),
+					faceMatch("face-2", "photo_1", 97),
+					faceMatch("face-1", "photo_1", 99),
+				},
+			}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	matches, err := faceIndexer.SearchPhotoMatchesWithBucket(context.TODO(), "bucket", "key.jpg", "event_1")
+	if err != nil {
+		t.Fatalf("error searching for face: %v", err)
+	}
+	want := []FaceMatchResult{
+		{FaceId: "face-1", ExternalImageId: "photo_1", Similarity: 99, Confidence: 99.5},
+		{FaceId: "face-3", ExternalImageId: "photo_2", Similarity: 85, Confidence: 99.5},
+	}
+	if !reflect.DeepEqual(matches, want) {
+		t.Fatalf("got %+v, want %+v", matches, want)
+	}
+}
+
+func TestNoMatchError(t *testing.T) {
+	faceIndexer := &rekognitionFaceIndexer{client: &fakeRekognition{}}
+
+	ctx := context.TODO()
+	matches, err := faceIndexer.SearchFaceWithBucket(ctx, "bucket", "key.jpg", "event_1")
+	if err != nil || len(matches) != 0 {
+		t.Fatalf("got %v, %v by default, want no matches and no error", matches, err)
+	}
+	if _, err := faceIndexer.SearchFaceWithBucket(ctx, "bucket", "key.jpg", "event_1", WithNoMatchError()); !errors.Is(err, ErrNoMatch) {
+		t.Fatalf("got error %v, want %v", err, ErrNoMatch)
+	}
+	if _, _, _, err := faceIndexer.SearchSelfieFace(ctx, testJPEG(t, 100, 100), "event_1", WithNoMatchError()); !errors.Is(err, ErrNoMatch) {
+		t.Fatalf("got error %v searching selfie, want %v", err, ErrNoMatch)
+	}
+	if _, err := faceIndexer.SearchFaceAcrossCollections(ctx, testJPEG(t, 100, 100), []string{"event_1", "event_2"}, 0, WithNoMatchError()); !errors.Is(err, ErrNoMatch) {
+		t.Fatalf("got error %v searching across collections, want %v", err, ErrNoMatch)
+	}
+	if _, err := faceIndexer.SearchManyByFaceIds(ctx, []string{"face-1", "face-2"}, "event_1", 2, WithNoMatchError()); !errors.Is(err, ErrNoMatch) {
+		t.Fatalf("got error %v searching many faces, want %v", err, ErrNoMatch)
+	}
+	if _, err := faceIndexer.SearchFaceInRegion(ctx, testJPEG(t, 400, 400), boundingBox(0, 0, 1, 1), "event_1", WithNoMatchError()); !errors.Is(err, ErrNoMatch) {
+		t.Fatalf("got error %v searching a region, want %v", err, ErrNoMatch)
+	}
+
+	// Results are still returned next to the error
+	selfieFaceIndexer := &rekognitionFaceIndexer{client: &fakeRekognition{
+		searchFacesByImage: func(*rekognition.SearchFacesByImageInput) (*rekognition.SearchFacesByImageOutput, error) {
+			bbox := boundingBox(0.25, 0.25, 0.5, 0.5)
+			return &rekognition.SearchFacesByImageOutput{SearchedFaceBoundingBox: &bbox}, nil
+		},
+	}}
+	result, err := selfieFaceIndexer.SearchSelfieFaceWithCrop(ctx, testJPEG(t, 200, 200), "event_1", WithNoMatchError())
+	if !errors.Is(err, ErrNoMatch) || len(result.Crop) == 0 {
+		t.Fatalf("got error %v with a %d byte crop, want %v with the crop", err, len(result.Crop), ErrNoMatch)
+	}
+}
+
+func TestSearchAndIndexSelfieFaceExcludesOwnId(t *testing.T) {
+	var selfieExternalImageId string
+	fake := &fakeRekognition{
+		indexFaces: func(input *rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			selfieExternalImageId = aws.ToString(input.ExternalImageId)
+			return &rekognition.IndexFacesOutput{
+				FaceRecords: []types.FaceRecord{{Face: &types.Face{FaceId: aws.String("selfie-face")}}},
+			}, nil
+		},
+		searchFaces: func(*rekognition.SearchFacesInput) (*rekognition.SearchFacesOutput, error) {
+			return &rekognition.SearchFacesOutput{
+				FaceMatches: []types.FaceMatch{
+					faceMatch("face-1", "photo_1", 99),
+					faceMatch("selfie-face-2", selfieExternalImageId, 99),
+				},
+			}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+	imageSelfie := testJPEG(t, 100, 100)
+
+	ctx := context.TODO()
+	_, matches, err := faceIndexer.SearchAndIndexSelfieFace(ctx, imageSelfie, "event_1")
+	if err != nil {
+		t.Fatalf("error searching for face: %v", err)
+	}
+	if want := []string{"photo_1"}; !reflect.DeepEqual(matches, want) {
+		t.Fatalf("got %v, want %v", matches, want)
+	}
+
+	_, matches, err = faceIndexer.SearchAndIndexSelfieFace(ctx, imageSelfie, "event_1", WithIncludeSearchedFace())
+	if err != nil {
+		t.Fatalf("error searching for face: %v", err)
+	}
+	if want := []string{"photo_1", selfieExternalImageId}; !reflect.DeepEqual(matches, want) {
+		t.Fatalf("got %v, want %v", matches, want)
+	}
+}
+
+func TestSearchFaceAcrossCollections(t *testing.T) {
+	fake := &fakeRekognition{
+		searchFacesByImage: func(input *rekognition.SearchFacesByImageInput) (*rekognition.SearchFacesByImageOutput, error) {
+			switch aws.ToString(input.CollectionId) {
+			case "event_1":
+				return &rekognition.SearchFacesByImageOutput{
+					FaceMatches:      []types.FaceMatch{faceMatch("face-1", "photo_1", 99)},
+					FaceModelVersion: aws.String("7.0"),
+				}, nil
+			case "event_2":
+				return &rekognition.SearchFacesByImageOutput{FaceModelVersion: aws.String("7.0")}, nil
+			case "event_old":
+				return &rekognition.SearchFacesByImageOutput{FaceModelVersion: aws.String("6.0")}, nil
+			default:
+				return nil, awsOperationError("SearchFacesByImage", "req-1", 400, &types.ResourceNotFoundException{})
+			}
+		},
+	}
+	var logs bytes.Buffer
+	faceIndexer := &rekognitionFaceIndexer{
+		client:  fake,
+		options: newOptions([]Option{WithLogger(slog.New(slog.NewTextHandler(&logs, nil)))}),
+	}
+
+	ctx := context.TODO()
+	results, err := faceIndexer.SearchFaceAcrossCollections(ctx, testJPEG(t, 100, 100), []string{"event_1", "event_2", "missing"}, 90)
+	if err != nil {
+		t.Fatalf("error searching across collections: %v", err)
+	}
+	want := map[string]CollectionSearchResult{
+		"event_1": {FaceModelVersion: "7.0", Matches: []FaceMatchResult{{FaceId: "face-1", ExternalImageId: "photo_1", Similarity: 99, Confidence: 99.5}}},
+		"event_2": {FaceModelVersion: "7.0", Matches: []FaceMatchResult{}},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Fatalf("got %+v, want %+v", results, want)
+	}
+	if strings.Contains(logs.String(), "different face model versions") {
+		t.Fatalf("got a face model warning for collections on the same version: %s", logs.String())
+	}
+
+	if _, err := faceIndexer.SearchFaceAcrossCollections(ctx, testJPEG(t, 100, 100), []string{"event_1", "event_old"}, 90); err != nil {
+		t.Fatalf("error searching across collections: %v", err)
+	}
+	if !strings.Contains(logs.String(), "different face model versions") {
+		t.Fatalf("expected a face model warning, got logs: %s", logs.String())
+	}
+}
+
+func TestSearchAndIndexSelfieFaceExternalImageIdGenerator(t *testing.T) {
+	var selfieExternalImageId string
+	fake := &fakeRekognition{
+		indexFaces: func(input *rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			selfieExternalImageId = aws.ToString(input.ExternalImageId)
+			return (&fakeRekognition{}).IndexFaces(context.TODO(), input)
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{
+		client: fake,
+		options: newOptions([]Option{WithExternalImageIdGenerator(func(collectionId string) string {
+			return "selfie:" + collectionId
+		})}),
+	}
+
+	if _, _, err := faceIndexer.SearchAndIndexSelfieFace(context.TODO(), testJPEG(t, 100, 100), "event_1"); err != nil {
+		t.Fatalf("error searching for face: %v", err)
+	}
+	if selfieExternalImageId != "selfie:event_1" {
+		t.Fatalf("got ExternalImageId %q, want %q", selfieExternalImageId, "selfie:event_1")
+	}
+}
+
+func TestSearchFacebyFaceIdMaxFacesAndThreshold(t *testing.T) {
+	var inputs []*rekognition.SearchFacesInput
+	fake := &fakeRekognition{
+		searchFaces: func(input *rekognition.SearchFacesInput) (*rekognition.SearchFacesOutput, error) {
+			inputs = append(inputs, input)
+			return &rekognition.SearchFacesOutput{}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	ctx := context.TODO()
+	if _, err := faceIndexer.SearchFacebyFaceId(ctx, "face-1", "event_1", WithMaxFaces(500), WithFaceMatchThreshold(95)); err != nil {
+		t.Fatalf("error searching face: %v", err)
+	}
+	if _, err := faceIndexer.SearchFacebyFaceId(ctx, "face-1", "event_1", WithMaxFaces(10000)); err != nil {
+		t.Fatalf("error searching face: %v", err)
+	}
+	if _, err := faceIndexer.SearchFacebyFaceId(ctx, "face-1", "event_1"); err != nil {
+		t.Fatalf("error searching face: %v", err)
+	}
+
+	if aws.ToInt32(inputs[0].MaxFaces) != 500 || aws.ToFloat32(inputs[0].FaceMatchThreshold) != 95 {
+		t.Fatalf("got MaxFaces %d and threshold %.0f, want 500 and 95", aws.ToInt32(inputs[0].MaxFaces), aws.ToFloat32(inputs[0].FaceMatchThreshold))
+	}
+	if aws.ToInt32(inputs[1].MaxFaces) != maxSearchFaces {
+		t.Fatalf("got MaxFaces %d, want it capped at %d", aws.ToInt32(inputs[1].MaxFaces), maxSearchFaces)
+	}
+	if inputs[2].MaxFaces != nil || inputs[2].FaceMatchThreshold != nil {
+		t.Fatal("set MaxFaces or threshold without the options")
+	}
+}
+
+func TestSearchFacebyFaceIdSingleCall(t *testing.T) {
+	fake := &fakeRekognition{}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	if _, err := faceIndexer.SearchFacebyFaceId(context.TODO(), "face-1", "event_1"); err != nil {
+		t.Fatalf("error searching face: %v", err)
+	}
+	if fake.count("SearchFaces") != 1 || fake.count("DescribeCollection") != 0 || fake.count("ListFaces") != 0 {
+		t.Fatalf("got %d SearchFaces, %d DescribeCollection and %d ListFaces calls, want a single SearchFaces",
+			fake.count("SearchFaces"), fake.count("DescribeCollection"), fake.count("ListFaces"))
+	}
+}
+
+func TestSearchSelfieFace(t *testing.T) {
+	fake := &fakeRekognition{
+		searchFacesByImage: func(*rekognition.SearchFacesByImageInput) (*rekognition.SearchFacesByImageOutput, error) {
+			bbox := boundingBox(0.25, 0.25, 0.5, 0.5)
+			return &rekognition.SearchFacesByImageOutput{
+				SearchedFaceBoundingBox: &bbox,
+				FaceMatches:             []types.FaceMatch{faceMatch("face-1", "photo_1", 99)},
+			}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	matches, searchedBox, crop, err := faceIndexer.SearchSelfieFace(context.TODO(), testJPEG(t, 200, 200), "event_1")
+	if err != nil {
+		t.Fatalf("error searching selfie: %v", err)
+	}
+	if want := []FaceMatchResult{{FaceId: "face-1", ExternalImageId: "photo_1", Similarity: 99, Confidence: 99.5}}; !reflect.DeepEqual(matches, want) {
+		t.Fatalf("got matches %v, want %v", matches, want)
+	}
+	if aws.ToFloat32(searchedBox.Width) != 0.5 {
+		t.Fatalf("got searched box %v, want the one Rekognition returned", bboxString(searchedBox))
+	}
+	config, err := jpeg.DecodeConfig(bytes.NewReader(crop))
+	if err != nil || config.Width != 100 {
+		t.Fatalf("got crop %dpx wide (%v), want 100", config.Width, err)
+	}
+	if fake.count("IndexFaces") != 0 || fake.count("CreateCollection") != 0 {
+		t.Fatal("searching a selfie wrote to the collection")
+	}
+}
+
+func TestSearchManyByFaceIds(t *testing.T) {
+	var (
+		mu             sync.Mutex
+		inFlight, peak int
+	)
+	fake := &fakeRekognition{
+		searchFaces: func(input *rekognition.SearchFacesInput) (*rekognition.SearchFacesOutput, error) {
+			mu.Lock()
+			inFlight++
+			peak = max(peak, inFlight)
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+
+			faceId := aws.ToString(input.FaceId)
+			if faceId == "face-deleted" {
+				return nil, awsOperationError("SearchFaces", "req-1", 400, &types.InvalidParameterException{})
+			}
+			return &rekognition.SearchFacesOutput{FaceMatches: []types.FaceMatch{faceMatch("match-"+faceId, "photo_"+faceId, 99)}}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	faceIds := []string{"face-deleted"}
+	for i := 0; i < 12; i++ {
+		faceIds = append(faceIds, fmt.Sprintf("face-%d", i))
+	}
+	results, err := faceIndexer.SearchManyByFaceIds(context.TODO(), faceIds, "event_1", 4)
+	var invalidParamErr *types.InvalidParameterException
+	if !errors.As(err, &invalidParamErr) || !strings.Contains(err.Error(), "face-deleted") {
+		t.Fatalf("got error %v, want the failed search of face-deleted", err)
+	}
+	if len(results) != 12 || !reflect.DeepEqual(results["face-3"], []string{"photo_face-3"}) {
+		t.Fatalf("got %v, want the matches of the 12 searchable faces", results)
+	}
+	if _, ok := results["face-deleted"]; ok {
+		t.Fatal("got a result for the failed search")
+	}
+	if peak > 4 || peak < 2 {
+		t.Fatalf("got %d searches in flight, want concurrency bounded at 4", peak)
+	}
+	if calls := fake.count("SearchFaces"); calls != len(faceIds) {
+		t.Fatalf("SearchFaces called %d times, want %d", calls, len(faceIds))
+	}
+}
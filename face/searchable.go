@@ -0,0 +1,96 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+const (
+	// defaultFaceSearchableMaxWait is how long a just-indexed face is waited for by default
+	defaultFaceSearchableMaxWait = 5 * time.Second
+	// faceSearchableInitialDelay is the first pause between SearchFaces attempts, doubled after each one
+	faceSearchableInitialDelay = 100 * time.Millisecond
+)
+
+// waitForFaceSearchable searches the collection for a just-indexed face, retrying while
+// Rekognition doesn't know the FaceId yet, see retryUntilSearchable. A FaceId the collection
+// doesn't store, e.g. a deleted one or one of another collection, will never be searchable,
+// so it fails at once with ErrFaceNotFound instead.
+func (r *rekognitionFaceIndexer) waitForFaceSearchable(ctx context.Context, collectionId string, faceId string, maxWait time.Duration, o callOptions) (*rekognition.SearchFacesOutput, error) {
+	input := searchFacesInput(collectionId, faceId, o)
+	attempts := 0
+	resp, err := retryUntilSearchable(ctx, maxWait, func() (*rekognition.SearchFacesOutput, error) {
+		attempts++
+		resp, err := invoke(ctx, r, "SearchFaces", r.client.SearchFaces, input, o.apiOptions...)
+		var invalidParamErr *types.InvalidParameterException
+		if attempts > 1 || !errors.As(err, &invalidParamErr) {
+			return resp, err
+		}
+
+		// Only wait for a face that is stored but not searchable yet
+		exists, existsErr := r.faceExists(ctx, collectionId, faceId, o)
+		if existsErr != nil {
+			return nil, existsErr
+		}
+		if !exists {
+			return nil, fmt.Errorf("%w: %s", ErrFaceNotFound, faceId)
+		}
+		return resp, err
+	})
+	r.recordConsistencyWaits("SearchFaces", attempts)
+	return resp, err
+}
+
+// retryUntilSearchable calls search, retrying with growing delays while it fails with
+// InvalidParameterException. IndexFaces is eventually consistent, so a search can briefly
+// reject a face that has just been indexed. Any other outcome is returned as is, and the
+// last error once the wait budget is spent. The budget is maxWait, cut short by the
+// context deadline: no retry is started that the deadline would cancel.
+func retryUntilSearchable[Out any](ctx context.Context, maxWait time.Duration, search func() (Out, error)) (Out, error) {
+	deadline := time.Now().Add(maxWait)
+	ctxDeadline, hasCtxDeadline := ctx.Deadline()
+	if hasCtxDeadline && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	} else {
+		hasCtxDeadline = false
+	}
+	delay := faceSearchableInitialDelay
+	for {
+		resp, err := search()
+		var invalidParamErr *types.InvalidParameterException
+		if err == nil || !errors.As(err, &invalidParamErr) {
+			return resp, err
+		}
+
+		// Give up with the last result when the budget can't fit another attempt
+		remaining := time.Until(deadline)
+		if remaining <= 0 || (hasCtxDeadline && delay >= remaining) {
+			return resp, err
+		}
+		timer := time.NewTimer(min(delay, remaining))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, ctx.Err()
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// faceSearchableMaxWait is the configured wait for a just-indexed face, or the default.
+// Quiet fast mode doesn't wait, so the face is searched once.
+func (r *rekognitionFaceIndexer) faceSearchableMaxWait() time.Duration {
+	if r.options.quietFastMode {
+		return 0
+	}
+	if r.options.faceSearchableMaxWait > 0 {
+		return r.options.faceSearchableMaxWait
+	}
+	return defaultFaceSearchableMaxWait
+}
@@ -0,0 +1,136 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func TestWaitForFaceSearchable(t *testing.T) {
+	fake := &fakeRekognition{}
+	fake.searchFaces = func(*rekognition.SearchFacesInput) (*rekognition.SearchFacesOutput, error) {
+		if fake.count("SearchFaces") < 3 {
+			return nil, &types.InvalidParameterException{Message: aws.String("face not found")}
+		}
+		return &rekognition.SearchFacesOutput{FaceMatches: []types.FaceMatch{faceMatch("face-1", "photo_1", 99)}}, nil
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	resp, err := faceIndexer.waitForFaceSearchable(context.TODO(), "event_1", "selfie-face", time.Second, callOptions{})
+	if err != nil {
+		t.Fatalf("error waiting for face: %v", err)
+	}
+	if len(resp.FaceMatches) != 1 || fake.count("SearchFaces") != 3 {
+		t.Fatalf("got %d matches after %d calls, want 1 after 3", len(resp.FaceMatches), fake.count("SearchFaces"))
+	}
+}
+
+func TestWaitForFaceSearchableGivesUp(t *testing.T) {
+	fake := &fakeRekognition{
+		searchFaces: func(*rekognition.SearchFacesInput) (*rekognition.SearchFacesOutput, error) {
+			return nil, &types.InvalidParameterException{Message: aws.String("face not found")}
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	start := time.Now()
+	_, err := faceIndexer.waitForFaceSearchable(context.TODO(), "event_1", "selfie-face", 250*time.Millisecond, callOptions{})
+	var invalidParamErr *types.InvalidParameterException
+	if !errors.As(err, &invalidParamErr) {
+		t.Fatalf("got error %v, want InvalidParameterException", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("gave up after %v, want about 250ms", elapsed)
+	}
+	if got := fake.count("SearchFaces"); got < 2 {
+		t.Fatalf("got %d SearchFaces calls, want retries", got)
+	}
+}
+
+func TestWaitForFaceSearchableContextDeadline(t *testing.T) {
+	fake := &fakeRekognition{
+		searchFaces: func(*rekognition.SearchFacesInput) (*rekognition.SearchFacesOutput, error) {
+			return nil, &types.InvalidParameterException{Message: aws.String("face not found")}
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+	ctx, cancel := context.WithTimeout(context.TODO(), 250*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := faceIndexer.waitForFaceSearchable(ctx, "event_1", "selfie-face", 10*time.Second, callOptions{})
+	var invalidParamErr *types.InvalidParameterException
+	if !errors.As(err, &invalidParamErr) {
+		t.Fatalf("got error %v, want the last InvalidParameterException", err)
+	}
+	if elapsed := time.Since(start); elapsed >= 250*time.Millisecond {
+		t.Fatalf("gave up after %v, want before the 250ms deadline", elapsed)
+	}
+	if got := fake.count("SearchFaces"); got < 2 {
+		t.Fatalf("got %d SearchFaces calls, want retries", got)
+	}
+}
+
+func TestWaitForFaceSearchableOtherError(t *testing.T) {
+	fake := &fakeRekognition{
+		searchFaces: func(*rekognition.SearchFacesInput) (*rekognition.SearchFacesOutput, error) {
+			return nil, &types.ResourceNotFoundException{Message: aws.String("collection not found")}
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	_, err := faceIndexer.waitForFaceSearchable(context.TODO(), "event_1", "selfie-face", time.Second, callOptions{})
+	var notFound *types.ResourceNotFoundException
+	if !errors.As(err, &notFound) {
+		t.Fatalf("got error %v, want ResourceNotFoundException", err)
+	}
+	if got := fake.count("SearchFaces"); got != 1 {
+		t.Fatalf("got %d SearchFaces calls, want 1", got)
+	}
+}
+
+func TestWaitForFaceSearchableUnknownFace(t *testing.T) {
+	fake := &fakeRekognition{
+		searchFaces: func(*rekognition.SearchFacesInput) (*rekognition.SearchFacesOutput, error) {
+			return nil, &types.InvalidParameterException{Message: aws.String("face not found")}
+		},
+		listFaces: pagedListFaces(nil, 1),
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	start := time.Now()
+	_, err := faceIndexer.waitForFaceSearchable(context.TODO(), "event_1", "deleted-face", 5*time.Second, callOptions{})
+	if !errors.Is(err, ErrFaceNotFound) {
+		t.Fatalf("got error %v, want %v", err, ErrFaceNotFound)
+	}
+	if elapsed := time.Since(start); elapsed > faceSearchableInitialDelay {
+		t.Fatalf("gave up after %v, want at once", elapsed)
+	}
+	if fake.count("SearchFaces") != 1 || fake.count("ListFaces") != 1 {
+		t.Fatalf("got %d SearchFaces and %d ListFaces calls, want 1 each", fake.count("SearchFaces"), fake.count("ListFaces"))
+	}
+}
+
+func TestSearchFacebyFaceIdWaitsForFace(t *testing.T) {
+	fake := &fakeRekognition{}
+	fake.searchFaces = func(*rekognition.SearchFacesInput) (*rekognition.SearchFacesOutput, error) {
+		if fake.count("SearchFaces") < 2 {
+			return nil, &types.InvalidParameterException{Message: aws.String("face not found")}
+		}
+		return &rekognition.SearchFacesOutput{FaceMatches: []types.FaceMatch{faceMatch("face-1", "photo_1", 99)}}, nil
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake, options: newOptions([]Option{WithFaceSearchableMaxWait(time.Second)})}
+
+	matches, err := faceIndexer.SearchFacebyFaceId(context.TODO(), "selfie-face", "event_1")
+	if err != nil {
+		t.Fatalf("error searching face: %v", err)
+	}
+	if len(matches) != 1 || fake.count("SearchFaces") != 2 {
+		t.Fatalf("got %d matches after %d calls, want 1 after 2", len(matches), fake.count("SearchFaces"))
+	}
+}
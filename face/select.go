@@ -0,0 +1,112 @@
+package face
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+	"github.com/samber/lo"
+)
+
+// FaceSelector picks the face to search among the faces detected in an image and returns
+// its index. Every face passed to it has a bounding box.
+type FaceSelector func(faces []types.FaceDetail) int
+
+// MostCentralFace is a FaceSelector picking the face whose center is closest to the center
+// of the image, e.g. the subject of a selfie rather than a larger face in the background.
+func MostCentralFace(faces []types.FaceDetail) int {
+	return closestFace(faces, 0.5, 0.5)
+}
+
+// LargestFace is a FaceSelector picking the face with the largest bounding box, the face
+// Rekognition searches when no FaceSelector is given.
+func LargestFace(faces []types.FaceDetail) int {
+	largest := 0
+	for i, face := range faces {
+		if boundingBoxArea(face.BoundingBox) > boundingBoxArea(faces[largest].BoundingBox) {
+			largest = i
+		}
+	}
+	return largest
+}
+
+// HighestConfidenceFace is a FaceSelector picking the face Rekognition is most confident is a face.
+func HighestConfidenceFace(faces []types.FaceDetail) int {
+	highest := 0
+	for i, face := range faces {
+		if aws.ToFloat32(face.Confidence) > aws.ToFloat32(faces[highest].Confidence) {
+			highest = i
+		}
+	}
+	return highest
+}
+
+// FaceAt returns a FaceSelector picking the face containing the normalized point (x, y),
+// e.g. where the user tapped, or the face closest to it when none does.
+func FaceAt(x, y float32) FaceSelector {
+	return func(faces []types.FaceDetail) int {
+		for i, face := range faces {
+			left, top := aws.ToFloat32(face.BoundingBox.Left), aws.ToFloat32(face.BoundingBox.Top)
+			if x >= left && x <= left+aws.ToFloat32(face.BoundingBox.Width) &&
+				y >= top && y <= top+aws.ToFloat32(face.BoundingBox.Height) {
+				return i
+			}
+		}
+		return closestFace(faces, x, y)
+	}
+}
+
+// closestFace returns the index of the face whose center is closest to the normalized point (x, y)
+func closestFace(faces []types.FaceDetail, x, y float32) int {
+	closest, closestDistance := 0, math.Inf(1)
+	for i, face := range faces {
+		bbox := face.BoundingBox
+		centerX := aws.ToFloat32(bbox.Left) + aws.ToFloat32(bbox.Width)/2
+		centerY := aws.ToFloat32(bbox.Top) + aws.ToFloat32(bbox.Height)/2
+		if distance := math.Hypot(float64(centerX-x), float64(centerY-y)); distance < closestDistance {
+			closest, closestDistance = i, distance
+		}
+	}
+	return closest
+}
+
+// searchSelectedFace detects every face in image, searches the one picked by the call's
+// FaceSelector on its own and returns its matches, bounding box and crop.
+func (r *rekognitionFaceIndexer) searchSelectedFace(ctx context.Context, image []byte, collectionId string, o callOptions) ([]FaceMatchResult, types.BoundingBox, []byte, error) {
+	faces, err := r.detectFaces(ctx, image, nil, o)
+	if err != nil {
+		return nil, types.BoundingBox{}, nil, err
+	}
+	faces = lo.Filter(faces, func(face types.FaceDetail, _ int) bool {
+		return face.BoundingBox != nil
+	})
+	if len(faces) == 0 {
+		return nil, types.BoundingBox{}, nil, ErrNoFaceDetected
+	}
+	selected := o.faceSelector(faces)
+	if selected < 0 || selected >= len(faces) {
+		return nil, types.BoundingBox{}, nil, fmt.Errorf("face selector picked face %d of %d", selected, len(faces))
+	}
+	bbox := *faces[selected].BoundingBox
+
+	// Bounding boxes refer to the upright image, so rotate before cropping
+	img, format, err := decodeUpright(image)
+	if err != nil {
+		return nil, types.BoundingBox{}, nil, err
+	}
+	matches, err := r.searchFaceRegion(ctx, img, format, bbox, collectionId, 0, o)
+	if err != nil {
+		return nil, types.BoundingBox{}, nil, err
+	}
+	cropped, err := CropFaceRegion(img, bbox, 1)
+	if err != nil {
+		return matches, bbox, nil, fmt.Errorf("failed to crop face %s: %w", bboxString(bbox), err)
+	}
+	crop, err := o.encodeCrop(cropped, format)
+	if err != nil {
+		return matches, bbox, nil, err
+	}
+	return matches, bbox, crop, nil
+}
@@ -0,0 +1,74 @@
+package face
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func TestFaceSelectors(t *testing.T) {
+	faces := []types.FaceDetail{
+		faceDetail(boundingBox(0, 0, 0.4, 0.4)),
+		faceDetail(boundingBox(0.45, 0.4, 0.15, 0.2)),
+		faceDetail(boundingBox(0.8, 0.7, 0.1, 0.1)),
+	}
+	faces[2].Confidence = aws.Float32(99.99)
+	tests := []struct {
+		name     string
+		selector FaceSelector
+		want     int
+	}{
+		{"most central", MostCentralFace, 1},
+		{"largest", LargestFace, 0},
+		{"highest confidence", HighestConfidenceFace, 2},
+		{"point inside a face", FaceAt(0.85, 0.75), 2},
+		{"point outside every face", FaceAt(0.1, 0.6), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.selector(faces); got != tt.want {
+				t.Fatalf("got face %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchSelfieFaceWithFaceSelector(t *testing.T) {
+	background := boundingBox(0, 0, 0.6, 0.6)
+	subject := boundingBox(0.4, 0.4, 0.25, 0.25)
+	var searched image.Config
+	fake := &fakeRekognition{
+		detectFaces: func(*rekognition.DetectFacesInput) (*rekognition.DetectFacesOutput, error) {
+			return &rekognition.DetectFacesOutput{FaceDetails: []types.FaceDetail{faceDetail(background), faceDetail(subject)}}, nil
+		},
+		searchFacesByImage: func(input *rekognition.SearchFacesByImageInput) (*rekognition.SearchFacesByImageOutput, error) {
+			searched, _, _ = image.DecodeConfig(bytes.NewReader(input.Image.Bytes))
+			return &rekognition.SearchFacesByImageOutput{FaceMatches: []types.FaceMatch{faceMatch("face-1", "photo_1", 99)}}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	matches, searchedBox, crop, err := faceIndexer.SearchSelfieFace(context.TODO(), testJPEG(t, 400, 400), "event_1", WithFaceSelector(MostCentralFace))
+	if err != nil {
+		t.Fatalf("error searching selfie: %v", err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("got %d matches, want 1", len(matches))
+	}
+	if aws.ToFloat32(searchedBox.Left) != 0.4 {
+		t.Fatalf("got searched box %v, want the central face", bboxString(searchedBox))
+	}
+	// The selected face is searched on its own, with margin
+	if searched.Width != 150 || searched.Height != 150 {
+		t.Fatalf("searched a %dx%d image, want the 150x150 crop of the central face", searched.Width, searched.Height)
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(crop))
+	if err != nil || config.Width != 100 {
+		t.Fatalf("got crop %dpx wide (%v), want 100", config.Width, err)
+	}
+}
@@ -0,0 +1,158 @@
+package face
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// SelfieResult is the outcome of SearchAndIndexSelfieFaceWithCrop.
+type SelfieResult struct {
+	// FaceId is the selfie's face, as indexed in the collection
+	FaceId string `json:"faceId"`
+	// Matches are the ExternalImageIds of the photos the selfie matched
+	Matches     []string          `json:"matches"`
+	BoundingBox types.BoundingBox `json:"boundingBox"`
+	// Crop is the selfie's face, encoded like the selfie unless overridden
+	Crop []byte `json:"crop"`
+	// CropWidth and CropHeight are the pixel dimensions of Crop
+	CropWidth  int `json:"cropWidth"`
+	CropHeight int `json:"cropHeight"`
+	// CropSource is the rectangle Crop was cut from, in pixels of the upright selfie
+	CropSource PixelRect `json:"cropSource"`
+	// CropURI is where WithCropUploader stored the crop, e.g. s3://bucket/key, empty without it
+	CropURI string `json:"cropUri,omitempty"`
+	// OriginalImage is the selfie exactly as it was passed in, before any rotation or
+	// downscaling, with WithOriginalImage; empty without it
+	OriginalImage []byte `json:"originalImage,omitempty"`
+}
+
+// PixelRect is a rectangle in pixels of an image, with X and Y its top-left corner.
+type PixelRect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+func newPixelRect(rect image.Rectangle) PixelRect {
+	return PixelRect{X: rect.Min.X, Y: rect.Min.Y, Width: rect.Dx(), Height: rect.Dy()}
+}
+
+// S3PutObjectAPI is the S3 operation used to upload crops. *s3.Client satisfies it.
+type S3PutObjectAPI interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// CropKeyFunc returns the S3 key a face crop is stored under.
+type CropKeyFunc func(collectionId string, faceId string) string
+
+// SearchAndIndexSelfieFaceWithCrop is SearchAndIndexSelfieFace also returning the bounding box
+// and a crop of the selfie's face. With WithCropUploader the crop is also written to S3 and
+// its URI returned.
+func (r *rekognitionFaceIndexer) SearchAndIndexSelfieFaceWithCrop(ctx context.Context, imageSelfie []byte, collectionId string, opts ...CallOption) (SelfieResult, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return SelfieResult{}, err
+	}
+
+	return r.selfieWithCrop(ctx, imageSelfie, collectionId, newCallOptions(opts))
+}
+
+// SearchSelfieFaceWithCrop is SearchAndIndexSelfieFaceWithCrop without indexing the selfie: a single
+// SearchFacesByImage call returns the matches directly from the selfie bytes, roughly halving the
+// latency of indexing, waiting and searching by FaceId. The result has no FaceId, and the crop isn't
+// uploaded by WithCropUploader, whose keys are built from it.
+func (r *rekognitionFaceIndexer) SearchSelfieFaceWithCrop(ctx context.Context, imageSelfie []byte, collectionId string, opts ...CallOption) (SelfieResult, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return SelfieResult{}, err
+	}
+
+	o := newCallOptions(opts)
+	o.searchOnly = true
+	return r.selfieWithCrop(ctx, imageSelfie, collectionId, o)
+}
+
+// selfieWithCrop searches the selfie, indexing it first unless the call is search only, and crops its face
+func (r *rekognitionFaceIndexer) selfieWithCrop(ctx context.Context, imageSelfie []byte, collectionId string, o callOptions) (SelfieResult, error) {
+	search := r.searchAndIndexSelfie
+	if o.searchOnly {
+		search = r.searchSelfie
+	}
+	selfie, err := search(ctx, imageSelfie, collectionId, o)
+	if err != nil {
+		return SelfieResult{}, err
+	}
+	result := SelfieResult{FaceId: selfie.faceId, Matches: selfie.matches, BoundingBox: selfie.boundingBox}
+	if o.originalImage {
+		result.OriginalImage = imageSelfie
+	}
+
+	// Bounding boxes refer to the upright image, so rotate before cropping
+	img, format, err := decodeUpright(imageSelfie)
+	if err != nil {
+		return result, fmt.Errorf("failed to crop selfie face: %w", err)
+	}
+	rect, err := faceRegionRect(img.Bounds(), selfie.boundingBox, 1)
+	if err != nil {
+		return result, fmt.Errorf("failed to crop selfie face: %w", err)
+	}
+	result.CropWidth, result.CropHeight = rect.Dx(), rect.Dy()
+	result.CropSource = newPixelRect(rect.Sub(img.Bounds().Min))
+	if result.Crop, err = o.encodeCrop(cropRect(img, rect), format); err != nil {
+		return result, fmt.Errorf("failed to crop selfie face: %w", err)
+	}
+
+	if r.options.cropUploader.client != nil && selfie.faceId != "" {
+		result.CropURI, err = r.uploadCrop(ctx, collectionId, selfie.faceId, result.Crop, o.cropFormat(format))
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, noMatchError(len(result.Matches), o)
+}
+
+// searchSelfie searches the largest face of the selfie against the collection with a single
+// SearchFacesByImage call, without indexing it
+func (r *rekognitionFaceIndexer) searchSelfie(ctx context.Context, imageSelfie []byte, collectionId string, o callOptions) (indexedSelfie, error) {
+	// Reject images Rekognition can't read before making any call
+	if _, err := validateImage(imageSelfie); err != nil {
+		return indexedSelfie{}, fmt.Errorf("search face failed: %w", err)
+	}
+
+	resp, err := r.searchFacesByImage(ctx, &types.Image{Bytes: imageSelfie}, collectionId, 0, o)
+	if err != nil {
+		return indexedSelfie{}, fmt.Errorf("search face failed: %w", err)
+	}
+	if resp.SearchedFaceBoundingBox == nil {
+		return indexedSelfie{}, fmt.Errorf("search face failed: %w", ErrNoFaceDetected)
+	}
+	return indexedSelfie{boundingBox: *resp.SearchedFaceBoundingBox, matches: matchedExternalImageIds(resp.FaceMatches, o)}, nil
+}
+
+// cropUploader stores face crops in S3, see WithCropUploader
+type cropUploader struct {
+	client S3PutObjectAPI
+	bucket string
+	key    CropKeyFunc
+}
+
+// uploadCrop writes the crop to the uploader's bucket and returns its s3:// URI
+func (r *rekognitionFaceIndexer) uploadCrop(ctx context.Context, collectionId string, faceId string, crop []byte, format ImageFormat) (string, error) {
+	uploader := r.options.cropUploader
+	key := uploader.key(collectionId, faceId)
+	_, err := uploader.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(uploader.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(crop),
+		ContentType: aws.String("image/" + string(format)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload crop to s3://%s/%s: %w", uploader.bucket, key, err)
+	}
+	return fmt.Sprintf("s3://%s/%s", uploader.bucket, key), nil
+}
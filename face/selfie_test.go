@@ -0,0 +1,144 @@
+package face
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeS3 records the objects put to it
+type fakeS3 struct {
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func (f *fakeS3) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	body, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	if f.objects == nil {
+		f.objects = make(map[string][]byte)
+		f.types = make(map[string]string)
+	}
+	name := aws.ToString(params.Bucket) + "/" + aws.ToString(params.Key)
+	f.objects[name] = body
+	f.types[name] = aws.ToString(params.ContentType)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func TestSearchAndIndexSelfieFaceWithCrop(t *testing.T) {
+	fake := &fakeRekognition{
+		indexFaces: func(input *rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			bbox := boundingBox(0.25, 0.25, 0.5, 0.5)
+			return &rekognition.IndexFacesOutput{
+				FaceRecords: []types.FaceRecord{{Face: &types.Face{FaceId: aws.String("selfie-face"), BoundingBox: &bbox}}},
+			}, nil
+		},
+		searchFaces: func(*rekognition.SearchFacesInput) (*rekognition.SearchFacesOutput, error) {
+			return &rekognition.SearchFacesOutput{FaceMatches: []types.FaceMatch{faceMatch("face-1", "photo_1", 99)}}, nil
+		},
+	}
+
+	// Bytes only by default
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+	result, err := faceIndexer.SearchAndIndexSelfieFaceWithCrop(context.TODO(), testJPEG(t, 200, 200), "event_1")
+	if err != nil {
+		t.Fatalf("error searching selfie: %v", err)
+	}
+	if result.FaceId != "selfie-face" || !reflect.DeepEqual(result.Matches, []string{"photo_1"}) || result.CropURI != "" || result.OriginalImage != nil {
+		t.Fatalf("got result %+v", result)
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(result.Crop))
+	if err != nil || config.Width != 100 || config.Height != 100 {
+		t.Fatalf("got a %dx%d crop (%v), want 100x100", config.Width, config.Height, err)
+	}
+	if result.CropWidth != 100 || result.CropHeight != 100 || result.CropSource != (PixelRect{X: 50, Y: 50, Width: 100, Height: 100}) {
+		t.Fatalf("got a %dx%d crop from %+v, want 100x100 from (50, 50)", result.CropWidth, result.CropHeight, result.CropSource)
+	}
+
+	// Uploaded to S3 when opted in
+	store := &fakeS3{}
+	faceIndexer = &rekognitionFaceIndexer{
+		client: fake,
+		options: newOptions([]Option{WithCropUploader(store, "crops", func(collectionId string, faceId string) string {
+			return collectionId + "/" + faceId + ".png"
+		})}),
+	}
+	result, err = faceIndexer.SearchAndIndexSelfieFaceWithCrop(context.TODO(), testPNG(t, 200, 200), "event_1")
+	if err != nil {
+		t.Fatalf("error searching selfie: %v", err)
+	}
+	if result.CropURI != "s3://crops/event_1/selfie-face.png" {
+		t.Fatalf("got crop URI %q", result.CropURI)
+	}
+	if !bytes.Equal(store.objects["crops/event_1/selfie-face.png"], result.Crop) || store.types["crops/event_1/selfie-face.png"] != "image/png" {
+		t.Fatal("the stored crop doesn't match the returned one")
+	}
+}
+
+func TestSearchAndIndexSelfieFaceWithOriginalImage(t *testing.T) {
+	fake := &fakeRekognition{
+		indexFaces: func(input *rekognition.IndexFacesInput) (*rekognition.IndexFacesOutput, error) {
+			bbox := boundingBox(0.25, 0.25, 0.5, 0.5)
+			return &rekognition.IndexFacesOutput{
+				FaceRecords: []types.FaceRecord{{Face: &types.Face{FaceId: aws.String("selfie-face"), BoundingBox: &bbox}}},
+			}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake, options: newOptions([]Option{WithAutoOrientOnUpload()})}
+
+	// A rotated selfie is sent upright, but the original bytes are returned as they were
+	selfie := withExifOrientation(testJPEG(t, 300, 200), 6)
+	result, err := faceIndexer.SearchAndIndexSelfieFaceWithCrop(context.TODO(), selfie, "event_1", WithOriginalImage())
+	if err != nil {
+		t.Fatalf("error searching selfie: %v", err)
+	}
+	if !bytes.Equal(result.OriginalImage, selfie) {
+		t.Fatal("the original image doesn't match the input bytes")
+	}
+}
+
+func TestSearchSelfieFaceWithCrop(t *testing.T) {
+	fake := &fakeRekognition{
+		searchFacesByImage: func(*rekognition.SearchFacesByImageInput) (*rekognition.SearchFacesByImageOutput, error) {
+			bbox := boundingBox(0.25, 0.25, 0.5, 0.5)
+			return &rekognition.SearchFacesByImageOutput{
+				SearchedFaceBoundingBox: &bbox,
+				FaceMatches:             []types.FaceMatch{faceMatch("face-1", "photo_1", 99), faceMatch("face-2", "photo_1", 95)},
+			}, nil
+		},
+	}
+	uploads := &fakeS3{}
+	faceIndexer := &rekognitionFaceIndexer{client: fake, options: newOptions([]Option{
+		WithCropUploader(uploads, "crops", func(collectionId string, faceId string) string { return collectionId + "/" + faceId }),
+	})}
+
+	result, err := faceIndexer.SearchSelfieFaceWithCrop(context.TODO(), testJPEG(t, 200, 200), "event_1")
+	if err != nil {
+		t.Fatalf("error searching selfie: %v", err)
+	}
+	if result.FaceId != "" || !reflect.DeepEqual(result.Matches, []string{"photo_1"}) || result.CropURI != "" {
+		t.Fatalf("got result %+v", result)
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(result.Crop))
+	if err != nil || config.Width != 100 || config.Height != 100 {
+		t.Fatalf("got crop %dx%d (%v), want 100x100", config.Width, config.Height, err)
+	}
+	for _, op := range []string{"IndexFaces", "SearchFaces"} {
+		if calls := fake.count(op); calls != 0 {
+			t.Fatalf("got %d %s calls, want none", calls, op)
+		}
+	}
+	if fake.count("SearchFacesByImage") != 1 || len(uploads.objects) != 0 {
+		t.Fatalf("got %d SearchFacesByImage calls and %d uploads, want 1 and none", fake.count("SearchFacesByImage"), len(uploads.objects))
+	}
+}
@@ -0,0 +1,83 @@
+package face
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+	"github.com/samber/lo"
+)
+
+// ImageFetcher returns the bytes of the photo a face was indexed from, by its ExternalImageId.
+type ImageFetcher func(externalImageId string) ([]byte, error)
+
+// CropStoredFace returns a crop of a stored face, e.g. to render an avatar from a FaceId.
+// Rekognition doesn't keep the source image, so the face's ExternalImageId is resolved
+// with ListFaces, the photo is fetched with fetchImage and the face is detected again in it.
+// The detected face overlapping the stored bounding box the most is cropped.
+func (r *rekognitionFaceIndexer) CropStoredFace(ctx context.Context, collectionId string, faceId string, fetchImage ImageFetcher, opts ...CallOption) ([]byte, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return nil, err
+	}
+	o := newCallOptions(opts)
+	resp, err := invoke(ctx, r, "ListFaces", r.client.ListFaces, &rekognition.ListFacesInput{
+		CollectionId: aws.String(collectionId),
+		FaceIds:      []string{faceId},
+	}, o.apiOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to crop stored face: %w", err)
+	}
+	stored, ok := lo.Find(resp.Faces, func(face types.Face) bool {
+		return aws.ToString(face.FaceId) == faceId
+	})
+	if !ok {
+		return nil, fmt.Errorf("failed to crop stored face: %w: %s", ErrFaceNotFound, faceId)
+	}
+
+	externalImageId := aws.ToString(stored.ExternalImageId)
+	image, err := fetchImage(externalImageId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch image %s: %w", externalImageId, err)
+	}
+
+	faces, err := r.detectFaces(ctx, image, nil, o)
+	if err != nil {
+		return nil, fmt.Errorf("failed to crop stored face: %w", err)
+	}
+	faces = lo.Filter(faces, func(face types.FaceDetail, _ int) bool {
+		return face.BoundingBox != nil
+	})
+	if len(faces) == 0 {
+		return nil, fmt.Errorf("failed to crop stored face: %w", ErrNoFaceDetected)
+	}
+
+	face := largestFace(faces)
+	if stored.BoundingBox != nil {
+		face = lo.MaxBy(faces, func(a, b types.FaceDetail) bool {
+			return boundingBoxOverlap(a.BoundingBox, stored.BoundingBox) > boundingBoxOverlap(b.BoundingBox, stored.BoundingBox)
+		})
+	}
+
+	// Bounding boxes refer to the upright image, so rotate before cropping
+	crop, err := cropFace(image, *face.BoundingBox, 1, o)
+	if err != nil {
+		return nil, fmt.Errorf("failed to crop face %s: %w", faceId, err)
+	}
+	return crop, nil
+}
+
+// boundingBoxOverlap is the intersection over union of two normalized boxes, 0 when they don't overlap
+func boundingBoxOverlap(a, b *types.BoundingBox) float32 {
+	left := max(aws.ToFloat32(a.Left), aws.ToFloat32(b.Left))
+	top := max(aws.ToFloat32(a.Top), aws.ToFloat32(b.Top))
+	right := min(aws.ToFloat32(a.Left)+aws.ToFloat32(a.Width), aws.ToFloat32(b.Left)+aws.ToFloat32(b.Width))
+	bottom := min(aws.ToFloat32(a.Top)+aws.ToFloat32(a.Height), aws.ToFloat32(b.Top)+aws.ToFloat32(b.Height))
+	if right <= left || bottom <= top {
+		return 0
+	}
+
+	intersection := (right - left) * (bottom - top)
+	return intersection / (boundingBoxArea(a) + boundingBoxArea(b) - intersection)
+}
@@ -0,0 +1,62 @@
+package face
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/jpeg"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func TestCropStoredFace(t *testing.T) {
+	stored := boundingBox(0.52, 0.48, 0.2, 0.2)
+	fake := &fakeRekognition{
+		listFaces: func(input *rekognition.ListFacesInput) (*rekognition.ListFacesOutput, error) {
+			if len(input.FaceIds) != 1 || input.FaceIds[0] != "face-2" {
+				return &rekognition.ListFacesOutput{}, nil
+			}
+			return &rekognition.ListFacesOutput{Faces: []types.Face{
+				{FaceId: aws.String("face-2"), ExternalImageId: aws.String("photo_1"), BoundingBox: &stored},
+			}}, nil
+		},
+		detectFaces: func(*rekognition.DetectFacesInput) (*rekognition.DetectFacesOutput, error) {
+			return &rekognition.DetectFacesOutput{FaceDetails: []types.FaceDetail{
+				faceDetail(boundingBox(0, 0, 0.4, 0.4)),
+				faceDetail(boundingBox(0.5, 0.5, 0.2, 0.2)),
+			}}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	var fetched string
+	fetchImage := func(externalImageId string) ([]byte, error) {
+		fetched = externalImageId
+		return testJPEG(t, 200, 200), nil
+	}
+
+	ctx := context.TODO()
+	crop, err := faceIndexer.CropStoredFace(ctx, "event_1", "face-2", fetchImage)
+	if err != nil {
+		t.Fatalf("error cropping stored face: %v", err)
+	}
+	if fetched != "photo_1" {
+		t.Fatalf("fetched %q, want photo_1", fetched)
+	}
+	// The face matching the stored box, not the largest one
+	config, err := jpeg.DecodeConfig(bytes.NewReader(crop))
+	if err != nil {
+		t.Fatalf("error decoding crop: %v", err)
+	}
+	if config.Width != 40 {
+		t.Fatalf("crop is %dpx wide, want 40", config.Width)
+	}
+
+	_, err = faceIndexer.CropStoredFace(ctx, "event_1", "face-missing", fetchImage)
+	if !errors.Is(err, ErrFaceNotFound) {
+		t.Fatalf("got error %v, want %v", err, ErrFaceNotFound)
+	}
+}
@@ -0,0 +1,80 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+// AssociateFacesResult is the outcome of grouping faces under a user.
+type AssociateFacesResult struct {
+	AssociatedFaceIds            []string                      `json:"associatedFaceIds"`
+	UnsuccessfulFaceAssociations []UnsuccessfulFaceAssociation `json:"unsuccessfulFaceAssociations"`
+	UserStatus                   types.UserStatus              `json:"userStatus"`
+}
+
+// UnsuccessfulFaceAssociation explains why a face wasn't grouped under the
+// user, e.g. LOW_MATCH_CONFIDENCE or ASSOCIATED_TO_A_DIFFERENT_USER.
+type UnsuccessfulFaceAssociation struct {
+	FaceId     string                                    `json:"faceId"`
+	Confidence float32                                   `json:"confidence"`
+	Reasons    []types.UnsuccessfulFaceAssociationReason `json:"reasons"`
+}
+
+// CreateUser creates a user in the collection, skipping the error when the user already exists
+func (r *rekognitionFaceIndexer) CreateUser(ctx context.Context, collectionId string, userId string) error {
+	if err := validateCollectionId(collectionId); err != nil {
+		return err
+	}
+	_, err := invoke(ctx, r, "CreateUser", r.client.CreateUser, &rekognition.CreateUserInput{
+		CollectionId: aws.String(collectionId),
+		UserId:       aws.String(userId),
+	})
+	if err != nil {
+		var conflict *types.ConflictException
+		if errors.As(err, &conflict) {
+			r.logger(ctx).Info("User already exists", "userId", userId, "collectionId", collectionId)
+			return nil
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	return nil
+}
+
+// AssociateFaces groups the given faces under the user. userMatchThreshold controls how
+// aggressively faces are grouped; pass 0 to use Rekognition's default threshold.
+func (r *rekognitionFaceIndexer) AssociateFaces(ctx context.Context, collectionId string, userId string, faceIds []string, userMatchThreshold float32) (AssociateFacesResult, error) {
+	if err := validateCollectionId(collectionId); err != nil {
+		return AssociateFacesResult{}, err
+	}
+	input := &rekognition.AssociateFacesInput{
+		CollectionId: aws.String(collectionId),
+		UserId:       aws.String(userId),
+		FaceIds:      faceIds,
+	}
+	if userMatchThreshold > 0 {
+		input.UserMatchThreshold = aws.Float32(userMatchThreshold)
+	}
+
+	resp, err := invoke(ctx, r, "AssociateFaces", r.client.AssociateFaces, input)
+	if err != nil {
+		return AssociateFacesResult{}, fmt.Errorf("failed to associate faces: %w", err)
+	}
+
+	result := AssociateFacesResult{UserStatus: resp.UserStatus}
+	for _, associated := range resp.AssociatedFaces {
+		result.AssociatedFaceIds = append(result.AssociatedFaceIds, aws.ToString(associated.FaceId))
+	}
+	for _, unsuccessful := range resp.UnsuccessfulFaceAssociations {
+		result.UnsuccessfulFaceAssociations = append(result.UnsuccessfulFaceAssociations, UnsuccessfulFaceAssociation{
+			FaceId:     aws.ToString(unsuccessful.FaceId),
+			Confidence: aws.ToFloat32(unsuccessful.Confidence),
+			Reasons:    unsuccessful.Reasons,
+		})
+	}
+	return result, nil
+}
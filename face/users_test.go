@@ -0,0 +1,58 @@
+package face
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition"
+	"github.com/aws/aws-sdk-go-v2/service/rekognition/types"
+)
+
+func TestAssociateFaces(t *testing.T) {
+	var gotThreshold *float32
+	fake := &fakeRekognition{
+		associateFaces: func(input *rekognition.AssociateFacesInput) (*rekognition.AssociateFacesOutput, error) {
+			gotThreshold = input.UserMatchThreshold
+			return &rekognition.AssociateFacesOutput{
+				AssociatedFaces: []types.AssociatedFace{{FaceId: aws.String("face-1")}},
+				UnsuccessfulFaceAssociations: []types.UnsuccessfulFaceAssociation{{
+					FaceId:     aws.String("face-2"),
+					Confidence: aws.Float32(40),
+					Reasons:    []types.UnsuccessfulFaceAssociationReason{types.UnsuccessfulFaceAssociationReasonLowMatchConfidence},
+				}},
+				UserStatus: types.UserStatusUpdating,
+			}, nil
+		},
+	}
+	faceIndexer := &rekognitionFaceIndexer{client: fake}
+
+	ctx := context.TODO()
+	result, err := faceIndexer.AssociateFaces(ctx, "event_1", "user_1", []string{"face-1", "face-2"}, 0)
+	if err != nil {
+		t.Fatalf("error associating faces: %v", err)
+	}
+	if gotThreshold != nil {
+		t.Fatalf("UserMatchThreshold sent as %v, want unset", *gotThreshold)
+	}
+	want := AssociateFacesResult{
+		AssociatedFaceIds: []string{"face-1"},
+		UnsuccessfulFaceAssociations: []UnsuccessfulFaceAssociation{{
+			FaceId:     "face-2",
+			Confidence: 40,
+			Reasons:    []types.UnsuccessfulFaceAssociationReason{types.UnsuccessfulFaceAssociationReasonLowMatchConfidence},
+		}},
+		UserStatus: types.UserStatusUpdating,
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Fatalf("got %+v, want %+v", result, want)
+	}
+
+	if _, err := faceIndexer.AssociateFaces(ctx, "event_1", "user_1", []string{"face-1"}, 90); err != nil {
+		t.Fatalf("error associating faces: %v", err)
+	}
+	if gotThreshold == nil || *gotThreshold != 90 {
+		t.Fatalf("UserMatchThreshold sent as %v, want 90", gotThreshold)
+	}
+}